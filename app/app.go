@@ -2,26 +2,36 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 
 	"github.com/benbjohnson/clock"
 	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis/v8"
 	"github.com/rs/zerolog"
 
 	"github.com/marktrs/gitsast/internal/queue"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/extra/bundebug"
 	"github.com/uptrace/bunrouter"
 	"github.com/urfave/cli/v2"
+	_ "modernc.org/sqlite"
 )
 
+// Version is the version of gitsast, set at build time with
+// -ldflags "-X github.com/marktrs/gitsast/app.Version=<version>"
+var Version = "dev"
+
 type appCtxKey struct{}
 
 type App struct {
@@ -44,6 +54,9 @@ type App struct {
 	dbOnce sync.Once
 	db     *bun.DB
 
+	redisOnce sync.Once
+	redis     *redis.Client
+
 	validator *validator.Validate
 }
 
@@ -77,8 +90,15 @@ func New(ctx context.Context, cfg *AppConfig) *App {
 	return app
 }
 
+// StartFromCLI - start the app with the config file of the --config flag,
+// or the embedded config of the --env flag when it is not set
 func StartFromCLI(c *cli.Context) (context.Context, *App, error) {
-	return Start(c.Context, c.Command.Name, c.String("env"))
+	cfg, err := LoadConfig(c.String("config"), c.Command.Name, c.String("env"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return StartWithConfig(c.Context, cfg)
 }
 
 func (app *App) SetClock(clock clock.Clock) {
@@ -88,8 +108,7 @@ func (app *App) SetClock(clock clock.Clock) {
 func Start(ctx context.Context, service, envName string) (context.Context, *App, error) {
 	cfg, err := LoadConfigFile(FS(), service, envName)
 	if err != nil {
-		_, _ = os.Stderr.WriteString(err.Error())
-		os.Exit(1)
+		return nil, nil, err
 	}
 
 	return StartWithConfig(ctx, cfg)
@@ -116,10 +135,22 @@ func WaitExitSignal() os.Signal {
 
 func (app *App) DB() *bun.DB {
 	app.dbOnce.Do(func() {
-		db := bun.NewDB(sql.OpenDB(
-			pgdriver.NewConnector(pgdriver.WithDSN(app.cfg.DB.DSN))),
-			pgdialect.New(),
-		)
+		var db *bun.DB
+
+		switch app.cfg.DB.GetDialect() {
+		case DialectSQLite:
+			// sql.Open only fails for unknown drivers
+			sqldb, _ := sql.Open(sqliteDriver, sqliteDSN(app.cfg.DB.DSN))
+			// sqlite serializes writes, and every connection of a :memory: DSN
+			// opens a new database
+			sqldb.SetMaxOpenConns(1)
+			db = bun.NewDB(sqldb, sqlitedialect.New())
+		default:
+			db = bun.NewDB(sql.OpenDB(
+				pgdriver.NewConnector(pgdriver.WithDSN(app.cfg.DB.DSN))),
+				pgdialect.New(),
+			)
+		}
 
 		db.AddQueryHook(bundebug.NewQueryHook(
 			bundebug.WithEnabled(app.IsDebug()),
@@ -131,6 +162,53 @@ func (app *App) DB() *bun.DB {
 	return app.db
 }
 
+// sqliteDriver is the name of the pure Go sqlite driver, it needs no cgo
+const sqliteDriver = "sqlite"
+
+// sqlitePragmas are set on every sqlite connection: writers wait for each other instead of
+// failing, and LIKE is case sensitive like on postgres
+var sqlitePragmas = []string{"busy_timeout(5000)", "case_sensitive_like(1)"}
+
+// sqliteDSN - add the pragmas gitsast relies on to a sqlite DSN
+func sqliteDSN(dsn string) string {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+
+	for _, pragma := range sqlitePragmas {
+		dsn += sep + "_pragma=" + url.QueryEscape(pragma)
+		sep = "&"
+	}
+
+	return dsn
+}
+
+func (app *App) Redis() *redis.Client {
+	app.redisOnce.Do(func() {
+		cfg := app.cfg.Queue
+
+		opt := &redis.Options{
+			Addr:       cfg.GetAddr(),
+			MaxRetries: 3,
+		}
+		if cfg != nil {
+			opt.Password = cfg.Password
+			opt.DB = cfg.DB
+			if cfg.TLS {
+				opt.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+		}
+
+		app.redis = redis.NewClient(opt)
+
+		app.OnAfterStop("redis", func(ctx context.Context, app *App) error {
+			return app.redis.Close()
+		})
+	})
+	return app.redis
+}
+
 func (app *App) Running() bool {
 	return !app.Stopping()
 }
@@ -152,8 +230,30 @@ func (app *App) OnAfterStop(name string, fn HookFunc) {
 	app.onAfterStop.Add(newHook(name, fn))
 }
 
+// queueInterruptionLimit is the number of interrupted runs of a scan the queue delivers on
+// top of its retry attempts. Scans count their attempts themselves without the interrupted
+// runs, the queue only drops the task of a scan interrupted more often than that.
+const queueInterruptionLimit = 10
+
 func (app *App) initQueue() {
-	app.queue = queue.NewHandler()
+	cfg := app.cfg.Queue
+
+	opt := &queue.Options{
+		Name:               cfg.GetScanName(),
+		DeadLetterName:     cfg.GetDeadLetterName(),
+		ReservationTimeout: cfg.GetReservationTimeout(),
+		RetryLimit:         cfg.GetRetry().GetMaxAttempts() + queueInterruptionLimit,
+	}
+	if cfg.GetBackend() == QueueBackendRedis {
+		opt.Redis = app.Redis()
+	}
+
+	q := queue.NewHandler(opt)
+	app.queue = q
+
+	app.OnStop("queue", func(ctx context.Context, app *App) error {
+		return q.Close()
+	})
 }
 
 func (app *App) Context() context.Context {
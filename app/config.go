@@ -3,11 +3,15 @@ package app
 import (
 	"embed"
 	"errors"
+	"fmt"
 	"io/fs"
+	"os"
 	"path"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,57 +33,346 @@ func FS() fs.FS {
 	return unwrappedFS
 }
 
-// Config holds data for application configuration
+// Config holds data for application configuration, fields left empty are set to
+// the value of their default tag when the config is loaded
 type AppConfig struct {
 	Server *Server   `yaml:"server,omitempty"`
-	DB     *Database `yaml:"database,omitempty"`
+	DB     *Database `yaml:"database,omitempty" validate:"required"`
+	Scan   *Scan     `yaml:"scan,omitempty"`
+	Queue  *Queue    `yaml:"queue,omitempty"`
+	Worker *Worker   `yaml:"worker,omitempty"`
+	// Redis is deprecated, its address is used by the redis queue backend when queue.addr is not set
+	Redis *Redis `yaml:"redis,omitempty"`
+	// Policy is the global policy of scans, repositories may override it with their own
+	Policy *Policy `yaml:"policy,omitempty"`
 
 	Debug   bool   `yaml:"debug,omitempty"`
 	Env     string `yaml:"env,omitempty"`
 	Service string `yaml:"service,omitempty"`
 }
 
+const (
+	// DialectPostgres stores data in a postgres database, the dsn is a postgres:// URL
+	DialectPostgres = "postgres"
+	// DialectSQLite stores data in a sqlite database file, the dsn is its path
+	// such as file:gitsast.db, or :memory: for a database living as long as the process
+	DialectSQLite = "sqlite"
+)
+
 // Database holds data for database configuration
 type Database struct {
-	DSN string `yaml:"dsn,omitempty"`
+	// Dialect is the database storing data, postgres or sqlite
+	Dialect string `yaml:"dialect,omitempty" default:"postgres" validate:"oneof=postgres sqlite"`
+	DSN     string `yaml:"dsn,omitempty" validate:"required"`
+}
+
+// GetDialect - return the database dialect
+func (d *Database) GetDialect() string {
+	return d.Dialect
 }
 
 // Server holds data for server configuration
 type Server struct {
-	Network         string        `yaml:"network,omitempty" default:"3000"`
-	Host            string        `yaml:"host,omitempty" default:"3000"`
-	Port            string        `yaml:"port,omitempty" default:"3000"`
-	ShutdownTimeout time.Duration `yaml:"shutdown_timeout,omitempty" default:"10s"`
-	ReadTimeout     time.Duration `yaml:"read_timeout,omitempty" default:"5s"`
-	WriteTimeout    time.Duration `yaml:"write_timeout,omitempty" default:"10s"`
-	IdleTimeout     time.Duration `yaml:"idle_timeout,omitempty" default:"60s"`
+	Network         string        `yaml:"network,omitempty" default:"tcp"`
+	Host            string        `yaml:"host,omitempty"`
+	Port            string        `yaml:"port,omitempty" default:"8080" validate:"numeric"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout,omitempty" default:"10s" validate:"gte=0"`
+	ReadTimeout     time.Duration `yaml:"read_timeout,omitempty" default:"5s" validate:"gte=0"`
+	WriteTimeout    time.Duration `yaml:"write_timeout,omitempty" default:"10s" validate:"gte=0"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout,omitempty" default:"60s" validate:"gte=0"`
+}
+
+// Redis holds data for redis configuration, it is superseded by the queue section
+type Redis struct {
+	Addr string `yaml:"addr,omitempty"`
+}
+
+const (
+	// QueueBackendRedis queues tasks in redis, they are consumed by any api or worker process
+	QueueBackendRedis = "redis"
+	// QueueBackendMemory queues tasks in the memory of the process adding them, they are only
+	// consumed by that process and lost when it stops
+	QueueBackendMemory = "memory"
+)
+
+// Queue holds data for task queue configuration
+type Queue struct {
+	// Backend is where tasks are queued, redis or memory
+	Backend string `yaml:"backend,omitempty" default:"redis" validate:"oneof=redis memory"`
+	// Addr, Password, DB and TLS connect to the redis server of the redis backend,
+	// it also relays the progress of scans to the API servers
+	Addr     string `yaml:"addr,omitempty" default:":6379"`
+	Password string `yaml:"password,omitempty"`
+	DB       int    `yaml:"db,omitempty" validate:"gte=0"`
+	TLS      bool   `yaml:"tls,omitempty"`
+	// ReservationTimeout is how long a worker holds a task before it is delivered to another
+	// worker, it should be longer than scan.timeout so running scans are not run twice
+	ReservationTimeout time.Duration `yaml:"reservation_timeout,omitempty" default:"15m" validate:"gte=0"`
+	Names              *QueueNames   `yaml:"names,omitempty"`
+	Retry              *QueueRetry   `yaml:"retry,omitempty"`
+}
+
+// QueueNames holds the names of the queues
+type QueueNames struct {
+	// Scan is the queue of scan tasks
+	Scan string `yaml:"scan,omitempty" default:"main-queue"`
+	// DeadLetter is the queue holding the scan tasks which ran out of retries, it is not consumed
+	DeadLetter string `yaml:"dead_letter,omitempty" default:"dead-letter"`
+}
+
+// QueueRetry holds the retry policy of scans failing with a transient error
+type QueueRetry struct {
+	// MaxAttempts is the number of times a scan runs before its report fails, including the first run
+	MaxAttempts int `yaml:"max_attempts,omitempty" default:"5" validate:"gte=1"`
+	// MinBackoff is the delay before the second attempt, it doubles with every further attempt
+	MinBackoff time.Duration `yaml:"min_backoff,omitempty" default:"30s" validate:"gte=0"`
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty" default:"10m" validate:"gte=0"`
+}
+
+// GetBackend - return the queue backend
+func (q *Queue) GetBackend() string {
+	return q.Backend
+}
+
+// IsMemory - report whether tasks are queued in memory
+func (q *Queue) IsMemory() bool {
+	return q.GetBackend() == QueueBackendMemory
+}
+
+// GetAddr - return the redis address
+func (q *Queue) GetAddr() string {
+	return q.Addr
+}
+
+// GetReservationTimeout - return the reservation timeout of tasks
+func (q *Queue) GetReservationTimeout() time.Duration {
+	return q.ReservationTimeout
+}
+
+// GetScanName - return the name of the scan queue
+func (q *Queue) GetScanName() string {
+	return q.Names.Scan
 }
 
-// Load returns config from yaml and environment variables.
+// GetDeadLetterName - return the name of the dead-letter queue
+func (q *Queue) GetDeadLetterName() string {
+	return q.Names.DeadLetter
+}
+
+// GetRetry - return the retry policy of scans
+func (q *Queue) GetRetry() *QueueRetry {
+	return q.Retry
+}
+
+// GetMaxAttempts - return the number of attempts of a scan
+func (r *QueueRetry) GetMaxAttempts() int {
+	return r.MaxAttempts
+}
+
+// GetMinBackoff - return the delay before the second attempt
+func (r *QueueRetry) GetMinBackoff() time.Duration {
+	return r.MinBackoff
+}
+
+// GetMaxBackoff - return the longest delay between two attempts
+func (r *QueueRetry) GetMaxBackoff() time.Duration {
+	return r.MaxBackoff
+}
+
+// Backoff - return the delay before the attempt following the given one, it doubles
+// with every attempt from the min backoff up to the max backoff
+func (r *QueueRetry) Backoff(attempt int) time.Duration {
+	delay, limit := r.GetMinBackoff(), r.GetMaxBackoff()
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
+
+// Scan holds data for scan configuration
+type Scan struct {
+	// Timeout is the deadline of a whole scan, including cloning the repository
+	Timeout time.Duration `yaml:"timeout,omitempty" default:"10m" validate:"gte=0"`
+	// FileTimeout is the time budget for scanning a single file
+	FileTimeout time.Duration `yaml:"file_timeout,omitempty" default:"30s" validate:"gte=0"`
+	// CancelPollInterval is how often a running scan checks if its report was cancelled
+	CancelPollInterval time.Duration `yaml:"cancel_poll_interval,omitempty" default:"2s" validate:"gte=0"`
+}
+
+// GetTimeout - return the scan deadline
+func (s *Scan) GetTimeout() time.Duration {
+	return s.Timeout
+}
+
+// GetFileTimeout - return the per-file budget
+func (s *Scan) GetFileTimeout() time.Duration {
+	return s.FileTimeout
+}
+
+// GetCancelPollInterval - return the cancellation poll interval
+func (s *Scan) GetCancelPollInterval() time.Duration {
+	return s.CancelPollInterval
+}
+
+// Worker holds data for queue consumer configuration
+type Worker struct {
+	// Concurrency is the number of scans run at the same time by a worker
+	Concurrency int `yaml:"concurrency,omitempty" default:"2" validate:"gte=1"`
+	// ShutdownTimeout is how long a stopping worker waits for running scans,
+	// scans still running afterwards are interrupted and re-queued
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout,omitempty" default:"25s" validate:"gte=0"`
+}
+
+// GetConcurrency - return the number of concurrent scans
+func (w *Worker) GetConcurrency() int {
+	return w.Concurrency
+}
+
+// GetShutdownTimeout - return the shutdown timeout
+func (w *Worker) GetShutdownTimeout() time.Duration {
+	return w.ShutdownTimeout
+}
+
+// DefaultConfig - return a config holding the default of every field, such as the flag
+// defaults of commands running without a config file
+func DefaultConfig() *AppConfig {
+	var c AppConfig
+	if err := applyDefaults(&c); err != nil {
+		panic(err)
+	}
+	return &c
+}
+
+// LoadConfig - load the config file at path, or the embedded config of env when path is empty.
+// See LoadConfigFile for the environment variables and defaults applied to it.
+func LoadConfig(path, service, env string) (*AppConfig, error) {
+	if path == "" {
+		return LoadConfigFile(FS(), service, env)
+	}
+
+	rawcfg, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	return parseConfig(rawcfg, service, env)
+}
+
+// LoadConfigFile - load the config of env from fsys. Values of the yaml file are overridden by
+// GITSAST_* environment variables, fields left empty are set to their default and the result
+// is validated.
 func LoadConfigFile(fsys fs.FS, service, env string) (*AppConfig, error) {
-	// default config
+	if env == "" {
+		env = "dev"
+	}
+
+	rawcfg, err := fs.ReadFile(fsys, path.Join("config", env+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	return parseConfig(rawcfg, service, env)
+}
+
+func parseConfig(rawcfg []byte, service, env string) (*AppConfig, error) {
 	var c AppConfig
 
 	if env == "" {
 		env = "dev"
 	}
 
-	// load from YAML config file
-	if rawcfg, err := fs.ReadFile(fsys, path.Join("config", env+".yaml")); err == nil {
-		if err := yaml.Unmarshal(rawcfg, &c); err != nil {
-			return nil, err
+	if err := yaml.Unmarshal(rawcfg, &c); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	if err := applyEnv(&c, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	// the redis section predates the queue section
+	if c.Redis != nil && c.Redis.Addr != "" {
+		if c.Queue == nil {
+			c.Queue = &Queue{}
 		}
-	} else {
-		return nil, err
+		if c.Queue.Addr == "" {
+			c.Queue.Addr = c.Redis.Addr
+		}
+	}
+
+	if err := applyDefaults(&c); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
 	}
 
 	c.Env = env
 	c.Service = service
 
-	// if dsn still empty, throw error
-	if c.DB.DSN == "" {
-		return nil, errors.New("database configuration is missing")
+	if err := validateConfig(&c); err != nil {
+		return nil, err
 	}
 
 	return &c, nil
 }
+
+// validateConfig - validate the config, errors name fields by their yaml path such as database.dsn
+func validateConfig(c *AppConfig) error {
+	v := validator.New()
+	v.RegisterTagNameFunc(yamlKey)
+
+	err := v.Struct(c)
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	msgs := make([]string, len(verrs))
+	for i, e := range verrs {
+		// the namespace starts with the name of the root struct
+		_, field, _ := strings.Cut(e.Namespace(), ".")
+		msgs[i] = field + " " + describeFieldError(e)
+	}
+
+	return fmt.Errorf("config: invalid configuration: %s", strings.Join(msgs, "; "))
+}
+
+func describeFieldError(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "numeric":
+		return "must be a number"
+	case "gte":
+		return "must be at least " + e.Param()
+	case "oneof":
+		return "must be one of " + e.Param()
+	}
+	return fmt.Sprintf("is invalid (%s)", e.Tag())
+}
+
+// Policy holds the clauses that pass or fail a scan, a scan fails when any clause is violated.
+// It is used as the global policy in configuration and as the policy of a repository.
+type Policy struct {
+	Clauses []*PolicyClause `json:"clauses" yaml:"clauses" validate:"dive,required"`
+}
+
+// PolicyClause is violated when more than MaxCount issues match all of its conditions,
+// issues triaged as false positive or accepted risk never match
+type PolicyClause struct {
+	// Name identifies the clause in policy results, it is generated from the conditions when empty
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Severity is the minimum severity of matching issues (LOW, MEDIUM, HIGH)
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty" validate:"omitempty,oneof=LOW MEDIUM HIGH low medium high"`
+	// RuleID restricts the clause to the issues of a rule, such as G010
+	RuleID string `json:"rule_id,omitempty" yaml:"rule_id,omitempty"`
+	// NewOnly restricts the clause to issues not in the baseline
+	NewOnly bool `json:"new_only,omitempty" yaml:"new_only,omitempty"`
+	// ExcludePaths are path patterns of issues left out, such as "test/", "/testdata/" or "*_test.go"
+	ExcludePaths []string `json:"exclude_paths,omitempty" yaml:"exclude_paths,omitempty"`
+	// MaxCount is the number of matching issues allowed, any matching issue violates the clause by default
+	MaxCount int `json:"max_count,omitempty" yaml:"max_count,omitempty" validate:"gte=0"`
+}
@@ -0,0 +1,212 @@
+package app_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigEmbedded(t *testing.T) {
+	cfg, err := app.LoadConfig("", "api", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", cfg.Env)
+	assert.Equal(t, "api", cfg.Service)
+	assert.Equal(t, app.DialectSQLite, cfg.DB.Dialect)
+	assert.Equal(t, ":memory:", cfg.DB.DSN)
+	assert.Equal(t, 5*time.Second, cfg.Server.ShutdownTimeout)
+}
+
+func TestLoadConfigFromFileWithDefaults(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  dsn: postgres://localhost/gitsast
+worker:
+  concurrency: 4
+`)
+
+	cfg, err := app.LoadConfig(path, "worker", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", cfg.Env)
+	assert.Equal(t, "postgres://localhost/gitsast", cfg.DB.DSN)
+	assert.Equal(t, app.DialectPostgres, cfg.DB.Dialect)
+
+	// missing sections and fields are set to their defaults
+	assert.Equal(t, "tcp", cfg.Server.Network)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, 10*time.Minute, cfg.Scan.Timeout)
+	assert.Equal(t, "redis", cfg.Queue.Backend)
+	assert.Equal(t, ":6379", cfg.Queue.Addr)
+	assert.Equal(t, "main-queue", cfg.Queue.Names.Scan)
+	assert.Equal(t, "dead-letter", cfg.Queue.Names.DeadLetter)
+	assert.Equal(t, 5, cfg.Queue.Retry.MaxAttempts)
+	assert.Equal(t, 30*time.Second, cfg.Queue.Retry.MinBackoff)
+	assert.Equal(t, 10*time.Minute, cfg.Queue.Retry.MaxBackoff)
+	assert.Equal(t, 4, cfg.Worker.Concurrency)
+	assert.Equal(t, 25*time.Second, cfg.Worker.ShutdownTimeout)
+
+	// sections without defaults are left out
+	assert.Nil(t, cfg.Policy)
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  dsn: postgres://localhost/gitsast
+scan:
+  timeout: 5m
+`)
+
+	t.Setenv("GITSAST_DATABASE_DSN", "postgres://db/gitsast")
+	t.Setenv("GITSAST_SCAN_TIMEOUT", "1h")
+	t.Setenv("GITSAST_SERVER_PORT", "9090")
+	t.Setenv("GITSAST_WORKER_CONCURRENCY", "8")
+	t.Setenv("GITSAST_DEBUG", "true")
+
+	cfg, err := app.LoadConfig(path, "api", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://db/gitsast", cfg.DB.DSN)
+	assert.Equal(t, time.Hour, cfg.Scan.Timeout)
+	assert.Equal(t, 30*time.Second, cfg.Scan.FileTimeout)
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, 8, cfg.Worker.Concurrency)
+	assert.True(t, cfg.Debug)
+}
+
+func TestLoadConfigEnvOnly(t *testing.T) {
+	t.Setenv("GITSAST_DATABASE_DSN", "postgres://db/gitsast")
+
+	cfg, err := app.LoadConfig(writeConfig(t, ""), "api", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://db/gitsast", cfg.DB.DSN)
+}
+
+func TestLoadConfigQueue(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  dsn: postgres://localhost/gitsast
+redis:
+  addr: redis:6379
+`)
+
+	// the address of the redis section is used when the queue section does not set one
+	cfg, err := app.LoadConfig(path, "api", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "redis:6379", cfg.Queue.GetAddr())
+	assert.False(t, cfg.Queue.IsMemory())
+
+	t.Setenv("GITSAST_QUEUE_BACKEND", "memory")
+	t.Setenv("GITSAST_QUEUE_NAMES_SCAN", "scans")
+
+	cfg, err = app.LoadConfig(path, "api", "dev")
+	assert.NoError(t, err)
+	assert.True(t, cfg.Queue.IsMemory())
+	assert.Equal(t, "scans", cfg.Queue.GetScanName())
+
+	t.Setenv("GITSAST_QUEUE_BACKEND", "kafka")
+
+	_, err = app.LoadConfig(path, "api", "dev")
+	assert.EqualError(t, err, "config: invalid configuration: queue.backend must be one of redis memory")
+}
+
+func TestLoadConfigQueueRetry(t *testing.T) {
+	t.Setenv("GITSAST_QUEUE_RETRY_MAX_ATTEMPTS", "3")
+	t.Setenv("GITSAST_QUEUE_RETRY_MIN_BACKOFF", "10s")
+	t.Setenv("GITSAST_QUEUE_RETRY_MAX_BACKOFF", "1m")
+	t.Setenv("GITSAST_QUEUE_NAMES_DEAD_LETTER", "failed-scans")
+
+	cfg, err := app.LoadConfig(writeConfig(t, "database: {dsn: postgres://localhost}\n"), "worker", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "failed-scans", cfg.Queue.GetDeadLetterName())
+
+	retry := cfg.Queue.GetRetry()
+	assert.Equal(t, 3, retry.GetMaxAttempts())
+	assert.Equal(t, 10*time.Second, retry.Backoff(1))
+	assert.Equal(t, 20*time.Second, retry.Backoff(2))
+	assert.Equal(t, 40*time.Second, retry.Backoff(3))
+	assert.Equal(t, time.Minute, retry.Backoff(4))
+
+	// the defaults apply without a retry section
+	defaults := app.DefaultConfig().Queue.GetRetry()
+	assert.Equal(t, 5, defaults.GetMaxAttempts())
+	assert.Equal(t, 30*time.Second, defaults.Backoff(1))
+	assert.Equal(t, 10*time.Minute, defaults.Backoff(10))
+
+	t.Setenv("GITSAST_QUEUE_RETRY_MAX_ATTEMPTS", "-1")
+
+	_, err = app.LoadConfig(writeConfig(t, "database: {dsn: postgres://localhost}\n"), "worker", "dev")
+	assert.EqualError(t, err, "config: invalid configuration: queue.retry.max_attempts must be at least 1")
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		err     string
+	}{
+		{
+			name: "missing database",
+			err:  "config: invalid configuration: database.dsn is required",
+		},
+		{
+			name:    "invalid dialect",
+			content: "database: {dialect: mysql, dsn: mysql://localhost}\n",
+			err:     "config: invalid configuration: database.dialect must be one of postgres sqlite",
+		},
+		{
+			name:    "missing dsn",
+			content: "database: {}\n",
+			err:     "config: invalid configuration: database.dsn is required",
+		},
+		{
+			name:    "invalid values",
+			content: "database: {dsn: postgres://localhost}\nserver: {port: http}\nworker: {concurrency: -1}\n",
+			err:     "config: invalid configuration: server.port must be a number; worker.concurrency must be at least 1",
+		},
+		{
+			name:    "invalid policy",
+			content: "database: {dsn: postgres://localhost}\npolicy:\n  clauses:\n    - severity: CRITICAL\n",
+			err:     "config: invalid configuration: policy.clauses[0].severity must be one of LOW MEDIUM HIGH low medium high",
+		},
+		{
+			name:    "invalid environment variable",
+			content: "database: {dsn: postgres://localhost}\n",
+			env:     map[string]string{"GITSAST_SCAN_TIMEOUT": "soon"},
+			err:     `config: invalid environment variable GITSAST_SCAN_TIMEOUT: time: invalid duration "soon"`,
+		},
+		{
+			name:    "invalid yaml",
+			content: "database: [\n",
+			err:     "config: yaml: line 1: did not find expected node content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := app.LoadConfig(writeConfig(t, tt.content), "api", "dev")
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, err := app.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), "api", "dev")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = app.LoadConfig("", "api", "staging")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
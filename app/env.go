@@ -0,0 +1,164 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the config, they are named
+// after the yaml keys of the config such as GITSAST_DATABASE_DSN or GITSAST_SCAN_TIMEOUT
+const EnvPrefix = "GITSAST"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fieldFunc is called with a config field, its struct field and its environment variable name,
+// it reports whether the field was changed
+type fieldFunc func(field reflect.Value, sf reflect.StructField, name string) (bool, error)
+
+// applyEnv - override the config with the environment variables returned by lookup
+func applyEnv(c *AppConfig, lookup func(string) (string, bool)) error {
+	_, err := visitConfig(reflect.ValueOf(c).Elem(), EnvPrefix,
+		func(field reflect.Value, sf reflect.StructField, name string) (bool, error) {
+			value, ok := lookup(name)
+			if !ok {
+				return false, nil
+			}
+
+			if err := setField(field, value); err != nil {
+				return false, fmt.Errorf("invalid environment variable %s: %w", name, err)
+			}
+			return true, nil
+		})
+	return err
+}
+
+// applyDefaults - set the zero fields of the config to the value of their default tag
+func applyDefaults(c *AppConfig) error {
+	_, err := visitConfig(reflect.ValueOf(c).Elem(), EnvPrefix,
+		func(field reflect.Value, sf reflect.StructField, name string) (bool, error) {
+			value, ok := sf.Tag.Lookup("default")
+			if !ok || !field.IsZero() {
+				return false, nil
+			}
+
+			if err := setField(field, value); err != nil {
+				return false, fmt.Errorf("invalid default of %s: %w", sf.Name, err)
+			}
+			return true, nil
+		})
+	return err
+}
+
+// visitConfig - call fn with every scalar field of the struct v. Sections missing from the
+// config are allocated and kept only when fn changed one of their fields.
+func visitConfig(v reflect.Value, prefix string, fn fieldFunc) (bool, error) {
+	changed := false
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		key := yamlKey(sf)
+		if !sf.IsExported() || key == "" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(key)
+		field := v.Field(i)
+
+		switch {
+		case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct:
+			section := field
+			if field.IsNil() {
+				section = reflect.New(field.Type().Elem())
+			}
+
+			ok, err := visitConfig(section.Elem(), name, fn)
+			if err != nil {
+				return false, err
+			}
+
+			if ok && field.IsNil() {
+				field.Set(section)
+			}
+			changed = changed || ok
+		case isScalar(field.Kind()):
+			ok, err := fn(field, sf, name)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || ok
+		}
+		// lists such as the policy clauses are only read from the config file
+	}
+
+	return changed, nil
+}
+
+// setField - parse a string value into a scalar field
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+func isScalar(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// yamlKey - the yaml key of a struct field, empty when the field is not read from yaml
+func yamlKey(sf reflect.StructField) string {
+	key, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+	if key == "-" {
+		return ""
+	}
+	return key
+}
@@ -2,9 +2,13 @@ package middleware
 
 import (
 	"database/sql"
+	"errors"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bunrouter"
 )
@@ -44,7 +48,84 @@ func (e HTTPError) Error() string {
 	return e.Message
 }
 
+// statusError is an error returned with its own status code and message
+type statusError struct {
+	statusCode int
+	code       string
+	err        error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// BadRequest - wrap the error of an invalid request, it is returned as 400 with its message
+func BadRequest(err error) error {
+	return &statusError{statusCode: http.StatusBadRequest, code: "bad_request", err: err}
+}
+
+// Conflict - wrap the error of a request conflicting with the state of a resource,
+// it is returned as 409 with its message
+func Conflict(err error) error {
+	return &statusError{statusCode: http.StatusConflict, code: "conflict", err: err}
+}
+
+// errorStatuses holds the status code of the errors registered by RegisterErrorStatus
+var errorStatuses sync.Map
+
+// RegisterErrorStatus - return the errors, and the errors wrapping them, with the status code
+// and their own message. Domains register the errors of their models with it.
+func RegisterErrorStatus(statusCode int, errs ...error) {
+	for _, err := range errs {
+		errorStatuses.Store(err, statusCode)
+	}
+}
+
+// registeredStatus - return the status code registered for the error, 0 when there is none
+func registeredStatus(err error) int {
+	statusCode := 0
+	errorStatuses.Range(func(target, code interface{}) bool {
+		if errors.Is(err, target.(error)) {
+			statusCode = code.(int)
+			return false
+		}
+		return true
+	})
+	return statusCode
+}
+
 func NewHTTPError(err error) HTTPError {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return HTTPError{
+			statusCode: statusErr.statusCode,
+			Code:       statusErr.code,
+			Message:    statusErr.Error(),
+		}
+	}
+
+	if statusCode := registeredStatus(err); statusCode != 0 {
+		return HTTPError{
+			statusCode: statusCode,
+			Code:       strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_"),
+			Message:    err.Error(),
+		}
+	}
+
+	// request bodies failing validation
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return HTTPError{
+			statusCode: http.StatusBadRequest,
+			Code:       "bad_request",
+			Message:    validationErrs.Error(),
+		}
+	}
+
 	switch err {
 	case io.EOF:
 		return HTTPError{
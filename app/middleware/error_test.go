@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/marktrs/gitsast/app/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bunrouter"
+)
+
+func TestErrorHandler(t *testing.T) {
+	errInProgress := middleware.Conflict(errors.New("the report is in progress"))
+
+	errNotCancellable := errors.New("the report cannot be cancelled")
+	middleware.RegisterErrorStatus(http.StatusConflict, errNotCancellable)
+
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		code       string
+		message    string
+	}{
+		{
+			name:       "bad request",
+			err:        middleware.BadRequest(errors.New("invalid query param value: order")),
+			statusCode: http.StatusBadRequest,
+			code:       "bad_request",
+			message:    "invalid query param value: order",
+		},
+		{
+			name:       "wrapped conflict",
+			err:        fmt.Errorf("cancel scan: %w", errInProgress),
+			statusCode: http.StatusConflict,
+			code:       "conflict",
+			message:    "the report is in progress",
+		},
+		{
+			name:       "registered error",
+			err:        fmt.Errorf("cancel scan: %w", errNotCancellable),
+			statusCode: http.StatusConflict,
+			code:       "conflict",
+			message:    "cancel scan: the report cannot be cancelled",
+		},
+		{
+			name:       "validation",
+			err:        validator.New().Var("", "required"),
+			statusCode: http.StatusBadRequest,
+			code:       "bad_request",
+			message:    "Key: '' Error:Field validation for '' failed on the 'required' tag",
+		},
+		{
+			name:       "not found",
+			err:        sql.ErrNoRows,
+			statusCode: http.StatusNotFound,
+			code:       "not_found",
+			message:    "Row not found",
+		},
+		{
+			name:       "internal",
+			err:        errors.New("connection refused"),
+			statusCode: http.StatusInternalServerError,
+			code:       "internal",
+			message:    "Internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := bunrouter.New(bunrouter.Use(middleware.ErrorHandler))
+			router.GET("/", func(w http.ResponseWriter, req bunrouter.Request) error {
+				return tt.err
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tt.statusCode, w.Code)
+
+			var body middleware.HTTPError
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body.Code)
+			assert.Equal(t, tt.message, body.Message)
+		})
+	}
+}
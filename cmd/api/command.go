@@ -1,11 +1,13 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/cmd/worker"
 	"github.com/marktrs/gitsast/internal/recover"
 	"github.com/urfave/cli/v2"
 )
@@ -14,18 +16,23 @@ func NewAPICommand() *cli.Command {
 	return &cli.Command{
 		Name:  "api",
 		Usage: "start GitSAST API server",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "addr",
 				Value: ":8000",
 				Usage: "serve address",
 			},
 			&cli.StringFlag{
-				Name:  "config",
-				Value: "./config/dev.yaml",
-				Usage: "path to environment config file",
+				Name:    "config",
+				EnvVars: []string{"GITSAST_CONFIG"},
+				Usage:   "path to a config file, the embedded config of --env is used when not set",
 			},
-		},
+			&cli.BoolFlag{
+				Name:  "worker",
+				Value: true,
+				Usage: "consume the scan queue in-process, disable with --worker=false when scans run in gitsast worker",
+			},
+		}, worker.Flags()...),
 		Action: func(c *cli.Context) error {
 			ctx, api, err := app.StartFromCLI(c)
 			if err != nil {
@@ -46,10 +53,13 @@ func NewAPICommand() *cli.Command {
 				Handler:      handler,
 			}
 
-			if err := api.Queue().StartConsumer(); err != nil {
-				return err
+			if c.Bool("worker") {
+				if err := worker.Start(ctx, c, api); err != nil {
+					return err
+				}
+			} else if cfg.Queue.IsMemory() {
+				log.Warn().Msg("scans are queued in memory and only run by this process, they will not run with --worker=false")
 			}
-			log.Info().Msg("started queue consumer")
 
 			log.Info().Msgf("listening on %s", srv.Addr)
 			go func() {
@@ -58,8 +68,21 @@ func NewAPICommand() *cli.Command {
 				}
 			}()
 
-			app.WaitExitSignal()
-			return srv.Shutdown(ctx)
+			sig := app.WaitExitSignal()
+			log.Info().Any("signal", sig).Msg("stopping server")
+
+			shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
+			defer cancel()
+
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				log.Err(err).Msg("failed to shutdown server")
+			}
+
+			if !c.Bool("worker") {
+				return nil
+			}
+
+			return worker.Stop(c, api)
 		},
 	}
 }
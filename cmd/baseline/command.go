@@ -0,0 +1,80 @@
+package baseline
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func NewBaselineCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "baseline",
+		Usage: "manage baselines of known findings",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"GITSAST_CONFIG"},
+				Usage:   "path to a config file, the embedded config of --env is used when not set",
+			},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "create a baseline file accepting every finding of an existing report",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "report",
+						Usage:    "report ID",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Value: model.BaselineFileName,
+						Usage: "path to write the baseline file to, - for stdout",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					ctx, app, err := app.StartFromCLI(c)
+					if err != nil {
+						return err
+					}
+					defer app.Stop()
+
+					reports := model.NewReportRepo(app)
+					report, err := reports.GetById(ctx, c.String("report"))
+					if err != nil {
+						return err
+					}
+
+					report.Issues, err = reports.GetIssues(ctx, report.ID)
+					if err != nil {
+						return err
+					}
+
+					b, err := json.MarshalIndent(model.NewBaseline(report), "", "  ")
+					if err != nil {
+						return err
+					}
+					b = append(b, '\n')
+
+					output := c.String("output")
+					if output == "-" {
+						_, err = c.App.Writer.Write(b)
+						return err
+					}
+
+					if err := os.WriteFile(output, b, 0644); err != nil {
+						return err
+					}
+
+					log.Info().Str("path", output).Msg("created baseline file")
+					return nil
+				},
+			},
+		},
+	}
+}
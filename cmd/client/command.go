@@ -0,0 +1,448 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/marktrs/gitsast/cmd/cmdutil"
+	"github.com/marktrs/gitsast/internal/client"
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/repository"
+	"github.com/urfave/cli/v2"
+)
+
+func NewClientCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "client",
+		Usage: "manage repositories and scans of a running gitsast API",
+		Description: "Connection settings are read from the flags, then the GITSAST_URL and GITSAST_TOKEN\n" +
+			"environment variables, then the client config file holding url, token and timeout keys.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "url",
+				EnvVars: []string{"GITSAST_URL"},
+				Usage:   "base URL of the gitsast API (default: " + client.DefaultURL + ")",
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				EnvVars: []string{"GITSAST_TOKEN"},
+				Usage:   "bearer token sent with every request",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"GITSAST_CLIENT_CONFIG"},
+				Usage:   "path to the client config file (default: gitsast/client.yaml in the user config directory)",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "deadline of each API request (default: 30s)",
+			},
+		},
+		Subcommands: []*cli.Command{
+			newRepoCommand(),
+			newScanCommand(),
+			newReportCommand(),
+		},
+	}
+}
+
+func newRepoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "repo",
+		Usage: "manage repositories",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "add a repository",
+				ArgsUsage: "<name> <remote URL>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "submodules",
+						Usage: "scan the submodules of the repository by default",
+					},
+					&cli.IntFlag{
+						Name:  "submodule-depth",
+						Usage: "maximum depth of nested submodules to scan by default",
+					},
+				},
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return errors.New("expected a name and a remote URL")
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					r := &repository.AddRepositoryRequest{
+						Name:      c.Args().Get(0),
+						RemoteURL: c.Args().Get(1),
+					}
+					if c.IsSet("submodules") || c.IsSet("submodule-depth") {
+						r.ScanOptions = &model.ScanOptions{
+							Submodules:     c.Bool("submodules"),
+							SubmoduleDepth: c.Int("submodule-depth"),
+						}
+					}
+
+					repo, err := api.AddRepository(c.Context, r)
+					if err != nil {
+						return err
+					}
+
+					return cmdutil.PrintJSON(c, repo)
+				}),
+			},
+			{
+				Name:  "list",
+				Usage: "list repositories",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "limit",
+						Value: 100,
+						Usage: "maximum number of repositories",
+					},
+					&cli.IntFlag{
+						Name:  "offset",
+						Usage: "number of repositories to skip",
+					},
+				},
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					repos, err := api.ListRepositories(c.Context, c.Int("limit"), c.Int("offset"))
+					if err != nil {
+						return err
+					}
+
+					return cmdutil.PrintJSON(c, repos)
+				}),
+			},
+			{
+				Name:      "get",
+				Usage:     "get a repository",
+				ArgsUsage: "<repository ID>",
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					id, err := singleArg(c, "repository ID")
+					if err != nil {
+						return err
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					repo, err := api.GetRepository(c.Context, id)
+					if err != nil {
+						return err
+					}
+
+					return cmdutil.PrintJSON(c, repo)
+				}),
+			},
+			{
+				Name:      "rm",
+				Usage:     "remove a repository",
+				ArgsUsage: "<repository ID>",
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					id, err := singleArg(c, "repository ID")
+					if err != nil {
+						return err
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					return api.RemoveRepository(c.Context, id)
+				}),
+			},
+		},
+	}
+}
+
+func newScanCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "scan",
+		Usage: "start, follow and cancel scans",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "start",
+				Usage:     "start a scan of a repository and print its report",
+				ArgsUsage: "<repository ID>",
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "submodules",
+						Usage: "scan submodules, instead of the repository default",
+					},
+					&cli.IntFlag{
+						Name:  "submodule-depth",
+						Usage: "maximum depth of nested submodules to scan, instead of the repository default",
+					},
+					&cli.BoolFlag{
+						Name:  "wait",
+						Usage: "wait until the scan is finished, see scan wait",
+					},
+				}, waitFlags()...),
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					id, err := singleArg(c, "repository ID")
+					if err != nil {
+						return err
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					var r *repository.ScanRequest
+					if c.IsSet("submodules") || c.IsSet("submodule-depth") {
+						r = &repository.ScanRequest{}
+						if c.IsSet("submodules") {
+							submodules := c.Bool("submodules")
+							r.Submodules = &submodules
+						}
+						if c.IsSet("submodule-depth") {
+							depth := c.Int("submodule-depth")
+							r.SubmoduleDepth = &depth
+						}
+					}
+
+					rep, err := api.StartScan(c.Context, id, r)
+					if err != nil {
+						return err
+					}
+
+					if !c.Bool("wait") {
+						return cmdutil.PrintJSON(c, rep)
+					}
+
+					fmt.Fprintf(c.App.ErrWriter, "started scan %s\n", rep.ID)
+					return wait(c, api, rep.ID)
+				}),
+			},
+			{
+				Name:      "wait",
+				Usage:     "wait until a scan is finished and print its summary",
+				ArgsUsage: "<report ID>",
+				Description: "Follows the progress events of the report, or polls its summary with --poll.\n" +
+					"Exits with code 1 when the scan failed, was cancelled or failed its policy,\n" +
+					"and 2 when the report cannot be followed.",
+				Flags: waitFlags(),
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					id, err := singleArg(c, "report ID")
+					if err != nil {
+						return err
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					return wait(c, api, id)
+				}),
+			},
+			{
+				Name:      "cancel",
+				Usage:     "cancel the queued or running scan of a repository",
+				ArgsUsage: "<repository ID>",
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					id, err := singleArg(c, "repository ID")
+					if err != nil {
+						return err
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					rep, err := api.CancelScan(c.Context, id)
+					if err != nil {
+						return err
+					}
+
+					return cmdutil.PrintJSON(c, rep)
+				}),
+			},
+		},
+	}
+}
+
+func newReportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "report",
+		Usage: "export reports",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "get",
+				Usage:     "export a report with its findings",
+				ArgsUsage: "<report ID>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: export.FormatJSON,
+						Usage: "output format (" + strings.Join(export.Formats(), ", ") + ")",
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Value: "-",
+						Usage: "path to write the report to, - for stdout",
+					},
+				},
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					id, err := singleArg(c, "report ID")
+					if err != nil {
+						return err
+					}
+
+					api, err := newClient(c)
+					if err != nil {
+						return err
+					}
+
+					if c.String("output") == "-" {
+						return api.ExportReport(c.Context, c.App.Writer, id, c.String("format"))
+					}
+
+					f, err := os.Create(c.String("output"))
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+
+					if err := api.ExportReport(c.Context, f, id, c.String("format")); err != nil {
+						return err
+					}
+
+					return f.Close()
+				}),
+			},
+		},
+	}
+}
+
+// waitFlags - flags of the commands waiting for a scan
+func waitFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "poll",
+			Usage: "poll the report summary instead of following progress events",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Value: 2 * time.Second,
+			Usage: "delay between polls of the report summary",
+		},
+		&cli.DurationFlag{
+			Name:  "wait-timeout",
+			Value: time.Hour,
+			Usage: "give up waiting after this duration",
+		},
+		&cli.BoolFlag{
+			Name:  "quiet",
+			Usage: "do not print progress",
+		},
+	}
+}
+
+// wait - wait until the scan of a report is finished, print its summary and return an exit
+// error when the scan did not succeed or failed its policy
+func wait(c *cli.Context, api *client.Client, id string) error {
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, c.Duration("wait-timeout"))
+	defer cancel()
+
+	opts := &client.WaitOptions{
+		Interval: c.Duration("interval"),
+		Poll:     c.Bool("poll"),
+	}
+	if !c.Bool("quiet") {
+		opts.OnProgress = func(p *model.Progress) {
+			fmt.Fprintf(c.App.ErrWriter, "%s %d%% (%d/%d files, %d issues)\n",
+				p.Stage, p.Percent, p.FilesScanned, p.FilesDiscovered, p.IssuesFound)
+		}
+	}
+
+	summary, err := api.Wait(ctx, id, opts)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("report %s is not finished after %s", id, c.Duration("wait-timeout"))
+		}
+		return err
+	}
+
+	if err := cmdutil.PrintJSON(c, summary); err != nil {
+		return err
+	}
+
+	return cmdutil.CheckSummary(summary)
+}
+
+// newClient - create an API client from the flags, the environment and the client config file
+func newClient(c *cli.Context) (*client.Client, error) {
+	cfg, err := loadConfig(c.String("config"))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.IsSet("url") {
+		cfg.URL = c.String("url")
+	}
+	if c.IsSet("token") {
+		cfg.Token = c.String("token")
+	}
+	if c.IsSet("timeout") {
+		cfg.Timeout = c.Duration("timeout")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	return client.New(cfg), nil
+}
+
+// loadConfig - read the given client config file, or the default one when it exists
+func loadConfig(path string) (*client.Config, error) {
+	if path != "" {
+		return client.LoadConfig(path)
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return &client.Config{}, nil
+	}
+
+	cfg, err := client.LoadConfig(filepath.Join(dir, "gitsast", "client.yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return &client.Config{}, nil
+	}
+
+	return cfg, err
+}
+
+// singleArg - the single argument of a command
+func singleArg(c *cli.Context, name string) (string, error) {
+	if c.NArg() != 1 {
+		return "", fmt.Errorf("expected a single %s", name)
+	}
+
+	return c.Args().First(), nil
+}
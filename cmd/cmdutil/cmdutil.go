@@ -0,0 +1,61 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/report"
+	"github.com/urfave/cli/v2"
+)
+
+// exit codes of the commands checking scans, CI pipelines tell failed scans from errors with them
+const (
+	// ExitFailed is the exit code of a scan which failed, was cancelled or failed its policy
+	ExitFailed = 1
+	// ExitError is the exit code of a command which cannot complete
+	ExitError = 2
+)
+
+// WithExitCode - exit with code 2 on errors, failed scans already carry their exit code
+func WithExitCode(action cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		err := action(c)
+		if err == nil {
+			return nil
+		}
+
+		var exitErr cli.ExitCoder
+		if errors.As(err, &exitErr) {
+			return err
+		}
+
+		return cli.Exit(err.Error(), ExitError)
+	}
+}
+
+// PrintJSON - print an indented JSON document to the output of the command
+func PrintJSON(c *cli.Context, v interface{}) error {
+	enc := json.NewEncoder(c.App.Writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// CheckPolicy - return an exit error if the policy failed
+func CheckPolicy(result *model.PolicyResult) error {
+	if result.Failed() {
+		return cli.Exit(fmt.Sprintf("policy failed with %d violated clause(s)", len(result.Violations)), ExitFailed)
+	}
+
+	return nil
+}
+
+// CheckSummary - return an exit error if the report did not succeed or failed its policy
+func CheckSummary(summary *report.SummaryResponse) error {
+	if summary.Status != model.StatusSuccess {
+		return cli.Exit(fmt.Sprintf("report %s is %s", summary.ReportID, summary.Status), ExitFailed)
+	}
+
+	return CheckPolicy(summary.PolicyResult)
+}
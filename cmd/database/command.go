@@ -1,70 +1,191 @@
 package database
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/marktrs/gitsast/app"
 	"github.com/urfave/cli/v2"
 )
 
+// errForce is returned by commands removing data when they are run without --force
+var errForce = errors.New("this command removes data, run it again with --force to confirm")
+
 func NewDBCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "db",
 		Usage: "manage database migrations",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:  "config",
-				Value: "./config/dev.yaml",
-				Usage: "path to environment config file",
+				Name:    "config",
+				EnvVars: []string{"GITSAST_CONFIG"},
+				Usage:   "path to a config file, the embedded config of --env is used when not set",
 			},
 		},
 		Subcommands: []*cli.Command{
 			{
 				Name:  "init",
-				Usage: "create migration tables",
+				Usage: "apply pending migrations and insert the initial rules, existing data is kept",
 				Action: func(c *cli.Context) error {
-					_, app, err := app.StartFromCLI(c)
+					ctx, app, err := app.StartFromCLI(c)
 					if err != nil {
 						return err
 					}
 					defer app.Stop()
-					return NewDBMigrator(app.DB()).Migrate()
+					return NewDBMigrator(app.DB()).Migrate(ctx)
 				},
 			},
 			{
-				Name:  "migrate tables",
-				Usage: "migrate tables",
-				Action: func(c *cli.Context) error {
-					_, app, err := app.StartFromCLI(c)
-					if err != nil {
-						return err
-					}
-					defer app.Stop()
-					return NewDBMigrator(app.DB()).CreateTablesIfNotExist()
+				Name:  "migrate",
+				Usage: "apply, roll back and create versioned migrations",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "up",
+						Usage: "apply pending migrations",
+						Action: func(c *cli.Context) error {
+							ctx, app, err := app.StartFromCLI(c)
+							if err != nil {
+								return err
+							}
+							defer app.Stop()
+
+							group, err := NewDBMigrator(app.DB()).MigrateUp(ctx)
+							if err != nil {
+								return err
+							}
+
+							if group.IsZero() {
+								fmt.Fprintln(c.App.Writer, "no pending migrations")
+								return nil
+							}
+
+							fmt.Fprintf(c.App.Writer, "migrated to %s\n", group)
+							return nil
+						},
+					},
+					{
+						Name:  "down",
+						Usage: "roll back the last group of applied migrations",
+						Flags: []cli.Flag{forceFlag()},
+						Action: func(c *cli.Context) error {
+							if !c.Bool("force") {
+								return errForce
+							}
+
+							ctx, app, err := app.StartFromCLI(c)
+							if err != nil {
+								return err
+							}
+							defer app.Stop()
+
+							group, err := NewDBMigrator(app.DB()).MigrateDown(ctx)
+							if err != nil {
+								return err
+							}
+
+							if group.IsZero() {
+								fmt.Fprintln(c.App.Writer, "no migrations to roll back")
+								return nil
+							}
+
+							fmt.Fprintf(c.App.Writer, "rolled back %s\n", group)
+							return nil
+						},
+					},
+					{
+						Name:  "status",
+						Usage: "list applied and pending migrations",
+						Action: func(c *cli.Context) error {
+							ctx, app, err := app.StartFromCLI(c)
+							if err != nil {
+								return err
+							}
+							defer app.Stop()
+
+							ms, err := NewDBMigrator(app.DB()).Status(ctx)
+							if err != nil {
+								return err
+							}
+
+							for _, m := range ms {
+								status := "pending"
+								if m.IsApplied() {
+									status = fmt.Sprintf("applied in group #%d at %s", m.GroupID, m.MigratedAt.Format("2006-01-02 15:04:05"))
+								}
+								fmt.Fprintf(c.App.Writer, "%s_%s\t%s\n", m.Name, m.Comment, status)
+							}
+
+							return nil
+						},
+					},
+					{
+						Name:      "create",
+						Usage:     "create the up and down SQL files of a new migration",
+						ArgsUsage: "<name>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "dir",
+								Value: "./cmd/database/migrations",
+								Usage: "directory of the migration files",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return errors.New("expected a single migration name, such as add_reports_duration")
+							}
+
+							// files are created without connecting to the database
+							files, err := NewDBMigrator(nil).Create(c.Context, c.String("dir"), c.Args().First())
+							if err != nil {
+								return err
+							}
+
+							for _, f := range files {
+								fmt.Fprintf(c.App.Writer, "created migration %s\n", f.Path)
+							}
+
+							return nil
+						},
+					},
 				},
 			},
 			{
 				Name:  "drop-tables",
 				Usage: "drop tables",
+				Flags: []cli.Flag{forceFlag()},
 				Action: func(c *cli.Context) error {
-					_, app, err := app.StartFromCLI(c)
+					if !c.Bool("force") {
+						return errForce
+					}
+
+					ctx, app, err := app.StartFromCLI(c)
 					if err != nil {
 						return err
 					}
 					defer app.Stop()
-					return NewDBMigrator(app.DB()).DropTable()
+					return NewDBMigrator(app.DB()).DropTable(ctx)
 				},
 			},
 			{
 				Name:  "init-rules",
 				Usage: "initialize rules",
 				Action: func(c *cli.Context) error {
-					_, app, err := app.StartFromCLI(c)
+					ctx, app, err := app.StartFromCLI(c)
 					if err != nil {
 						return err
 					}
 					defer app.Stop()
-					return NewDBMigrator(app.DB()).DropTable()
+					return NewDBMigrator(app.DB()).InsertInitialRulesIfNotExist(ctx)
 				},
 			},
 		},
 	}
 }
+
+// forceFlag - confirms commands removing data
+func forceFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "force",
+		Usage: "confirm that data is removed",
+	}
+}
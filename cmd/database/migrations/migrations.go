@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/migrate"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var sqlMigrations embed.FS
+
+// Dirs are the directories of the migrations of each dialect, every migration
+// is written for both dialects under the same name
+var Dirs = map[dialect.Name]string{
+	dialect.PG:     "postgres",
+	dialect.SQLite: "sqlite",
+}
+
+// Postgres and SQLite are the versioned migrations of the database schema, applied in the order
+// of their timestamp prefix. A migration is never edited once released, changes go into a new one.
+var (
+	Postgres = discover(Dirs[dialect.PG])
+	SQLite   = discover(Dirs[dialect.SQLite])
+)
+
+// ForDialect - return the migrations of a dialect, nil when it is not supported
+func ForDialect(name dialect.Name) *migrate.Migrations {
+	switch name {
+	case dialect.PG:
+		return Postgres
+	case dialect.SQLite:
+		return SQLite
+	}
+	return nil
+}
+
+func discover(dir string) *migrate.Migrations {
+	fsys, err := fs.Sub(sqlMigrations, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	migrations := migrate.NewMigrations()
+	if err := migrations.Discover(fsys); err != nil {
+		panic(err)
+	}
+	return migrations
+}
@@ -0,0 +1,134 @@
+CREATE TABLE IF NOT EXISTS "rules" (
+  "id" BIGSERIAL NOT NULL,
+  "name" VARCHAR NOT NULL,
+  "keyword" VARCHAR NOT NULL,
+  "description" VARCHAR NOT NULL,
+  "severity" BIGINT NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("id"),
+  UNIQUE ("name"),
+  UNIQUE ("keyword")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "repositories" (
+  "id" VARCHAR NOT NULL,
+  "name" VARCHAR NOT NULL,
+  "remote_url" VARCHAR NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  "scan_options" jsonb,
+  "baseline" jsonb,
+  "policy" jsonb,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "reports" (
+  "id" VARCHAR NOT NULL,
+  "repository_id" uuid,
+  "status" VARCHAR DEFAULT 'initialized',
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  "enqueue_at" TIMESTAMPTZ,
+  "started_at" TIMESTAMPTZ,
+  "finished_at" TIMESTAMPTZ,
+  "failed_reason" VARCHAR,
+  "options" jsonb,
+  "submodules" jsonb,
+  "progress" jsonb,
+  "summary" jsonb,
+  "policy_result" jsonb,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "issues" (
+  "id" BIGSERIAL NOT NULL,
+  "report_id" uuid NOT NULL,
+  "rule_id" VARCHAR NOT NULL,
+  "location_path" VARCHAR,
+  "location_line" BIGINT,
+  "location_end_line" BIGINT,
+  "description" VARCHAR,
+  "severity" VARCHAR NOT NULL,
+  "keyword" VARCHAR,
+  "fingerprint" VARCHAR,
+  "snippet" VARCHAR,
+  "status" VARCHAR,
+  "occurrences" BIGINT,
+  "submodule" jsonb,
+  "commit" jsonb,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "finding_triage" (
+  "repository_id" uuid NOT NULL,
+  "fingerprint" VARCHAR NOT NULL,
+  "state" VARCHAR NOT NULL DEFAULT 'open',
+  "assignee" VARCHAR,
+  "expires_at" TIMESTAMPTZ,
+  "comments" jsonb,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("repository_id", "fingerprint")
+);
+
+--bun:split
+
+-- tables created by an earlier db init are kept, they only miss the columns added since
+
+ALTER TABLE "repositories"
+  ADD COLUMN IF NOT EXISTS "scan_options" jsonb,
+  ADD COLUMN IF NOT EXISTS "baseline" jsonb,
+  ADD COLUMN IF NOT EXISTS "policy" jsonb;
+
+--bun:split
+
+ALTER TABLE "reports"
+  ADD COLUMN IF NOT EXISTS "options" jsonb,
+  ADD COLUMN IF NOT EXISTS "submodules" jsonb,
+  ADD COLUMN IF NOT EXISTS "progress" jsonb,
+  ADD COLUMN IF NOT EXISTS "summary" jsonb,
+  ADD COLUMN IF NOT EXISTS "policy_result" jsonb;
+
+--bun:split
+
+ALTER TABLE "issues"
+  ADD COLUMN IF NOT EXISTS "location_end_line" BIGINT,
+  ADD COLUMN IF NOT EXISTS "fingerprint" VARCHAR,
+  ADD COLUMN IF NOT EXISTS "snippet" VARCHAR,
+  ADD COLUMN IF NOT EXISTS "status" VARCHAR,
+  ADD COLUMN IF NOT EXISTS "occurrences" BIGINT,
+  ADD COLUMN IF NOT EXISTS "submodule" jsonb,
+  ADD COLUMN IF NOT EXISTS "commit" jsonb;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "reports_repository_id_created_at_idx" ON "reports" ("repository_id", "created_at");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_id_idx" ON "issues" ("report_id", "id");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_severity_idx" ON "issues" ("report_id", "severity", "id");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_rule_id_idx" ON "issues" ("report_id", "rule_id", "id");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_location_path_idx" ON "issues" ("report_id", "location_path" text_pattern_ops);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_fingerprint_idx" ON "issues" ("fingerprint");
@@ -0,0 +1,3 @@
+ALTER TABLE "reports"
+  DROP COLUMN IF EXISTS "failure_kind",
+  DROP COLUMN IF EXISTS "attempts";
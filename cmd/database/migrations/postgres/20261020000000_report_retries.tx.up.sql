@@ -0,0 +1,3 @@
+ALTER TABLE "reports"
+  ADD COLUMN IF NOT EXISTS "failure_kind" VARCHAR,
+  ADD COLUMN IF NOT EXISTS "attempts" BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+ALTER TABLE "finding_triage" ADD COLUMN IF NOT EXISTS "comments" jsonb;
+
+--bun:split
+
+UPDATE "finding_triage" AS t SET "comments" = (
+  SELECT jsonb_agg(jsonb_build_object('author', c."author", 'body', c."body", 'created_at', c."created_at") ORDER BY c."id")
+  FROM "triage_comments" AS c
+  WHERE c."repository_id" = t."repository_id" AND c."fingerprint" = t."fingerprint"
+);
+
+--bun:split
+
+DROP TABLE IF EXISTS "triage_comments";
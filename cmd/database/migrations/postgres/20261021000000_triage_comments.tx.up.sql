@@ -0,0 +1,33 @@
+-- comments were stored as a jsonb array of their triage, concurrent comments overwrote each other
+
+CREATE TABLE IF NOT EXISTS "triage_comments" (
+  "id" BIGSERIAL NOT NULL,
+  "repository_id" uuid NOT NULL,
+  "fingerprint" VARCHAR NOT NULL,
+  "author" VARCHAR,
+  "body" VARCHAR,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "triage_comments_repository_id_fingerprint_idx" ON "triage_comments" ("repository_id", "fingerprint", "id");
+
+--bun:split
+
+INSERT INTO "triage_comments" ("repository_id", "fingerprint", "author", "body", "created_at")
+SELECT
+  t."repository_id",
+  t."fingerprint",
+  c.value->>'author',
+  c.value->>'body',
+  COALESCE((c.value->>'created_at')::timestamptz, t."updated_at")
+FROM "finding_triage" AS t,
+  jsonb_array_elements(CASE WHEN jsonb_typeof(t."comments") = 'array' THEN t."comments" ELSE '[]'::jsonb END)
+    WITH ORDINALITY AS c(value, position)
+ORDER BY t."repository_id", t."fingerprint", c.position;
+
+--bun:split
+
+ALTER TABLE "finding_triage" DROP COLUMN IF EXISTS "comments";
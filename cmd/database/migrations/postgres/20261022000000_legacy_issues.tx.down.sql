@@ -0,0 +1,15 @@
+ALTER TABLE "reports" ADD COLUMN IF NOT EXISTS "issues" jsonb;
+
+--bun:split
+
+UPDATE "reports" AS r SET "issues" = (
+  SELECT jsonb_agg(jsonb_build_object(
+    'ruleId', i."rule_id",
+    'location', jsonb_build_object('path', i."location_path", 'line', i."location_line"),
+    'description', i."description",
+    'severity', i."severity",
+    'keyword', i."keyword"
+  ) ORDER BY i."id")
+  FROM "issues" AS i
+  WHERE i."report_id" = r."id"::uuid
+);
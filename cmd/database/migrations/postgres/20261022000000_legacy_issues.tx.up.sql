@@ -0,0 +1,29 @@
+-- issues of reports created before the issues table were stored as a jsonb array of the report,
+-- they are copied into the issues table once and the legacy column is dropped
+
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = current_schema() AND table_name = 'reports' AND column_name = 'issues'
+  ) THEN
+    INSERT INTO "issues" ("report_id", "rule_id", "location_path", "location_line", "description", "severity", "keyword", "status", "occurrences")
+    SELECT
+      r."id"::uuid,
+      COALESCE(i.value->>'ruleId', ''),
+      i.value->'location'->>'path',
+      (i.value->'location'->>'line')::bigint,
+      i.value->>'description',
+      COALESCE(i.value->>'severity', ''),
+      i.value->>'keyword',
+      'active',
+      1
+    FROM "reports" AS r,
+      jsonb_array_elements(CASE WHEN jsonb_typeof(r."issues") = 'array' THEN r."issues" ELSE '[]'::jsonb END)
+        WITH ORDINALITY AS i(value, position)
+    WHERE NOT EXISTS (SELECT 1 FROM "issues" WHERE "issues"."report_id" = r."id"::uuid)
+    ORDER BY r."id", i.position;
+
+    ALTER TABLE "reports" DROP COLUMN "issues";
+  END IF;
+END $$;
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "finding_triage";
+
+--bun:split
+
+DROP TABLE IF EXISTS "issues";
+
+--bun:split
+
+DROP TABLE IF EXISTS "reports";
+
+--bun:split
+
+DROP TABLE IF EXISTS "repositories";
+
+--bun:split
+
+DROP TABLE IF EXISTS "rules";
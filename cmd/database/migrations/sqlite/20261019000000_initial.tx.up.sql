@@ -0,0 +1,109 @@
+-- JSON documents are stored as TEXT, postgres stores them as jsonb
+
+CREATE TABLE IF NOT EXISTS "rules" (
+  "id" INTEGER NOT NULL,
+  "name" TEXT NOT NULL,
+  "keyword" TEXT NOT NULL,
+  "description" TEXT NOT NULL,
+  "severity" INTEGER NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("id"),
+  UNIQUE ("name"),
+  UNIQUE ("keyword")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "repositories" (
+  "id" TEXT NOT NULL,
+  "name" TEXT NOT NULL,
+  "remote_url" TEXT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "scan_options" TEXT,
+  "baseline" TEXT,
+  "policy" TEXT,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "reports" (
+  "id" TEXT NOT NULL,
+  "repository_id" TEXT,
+  "status" TEXT DEFAULT 'initialized',
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "enqueue_at" TIMESTAMP,
+  "started_at" TIMESTAMP,
+  "finished_at" TIMESTAMP,
+  "failed_reason" TEXT,
+  "options" TEXT,
+  "submodules" TEXT,
+  "progress" TEXT,
+  "summary" TEXT,
+  "policy_result" TEXT,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "issues" (
+  "id" INTEGER NOT NULL,
+  "report_id" TEXT NOT NULL,
+  "rule_id" TEXT NOT NULL,
+  "location_path" TEXT,
+  "location_line" INTEGER,
+  "location_end_line" INTEGER,
+  "description" TEXT,
+  "severity" TEXT NOT NULL,
+  "keyword" TEXT,
+  "fingerprint" TEXT,
+  "snippet" TEXT,
+  "status" TEXT,
+  "occurrences" INTEGER,
+  "submodule" TEXT,
+  "commit" TEXT,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "finding_triage" (
+  "repository_id" TEXT NOT NULL,
+  "fingerprint" TEXT NOT NULL,
+  "state" TEXT NOT NULL DEFAULT 'open',
+  "assignee" TEXT,
+  "expires_at" TIMESTAMP,
+  "comments" TEXT,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("repository_id", "fingerprint")
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "reports_repository_id_created_at_idx" ON "reports" ("repository_id", "created_at");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_id_idx" ON "issues" ("report_id", "id");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_severity_idx" ON "issues" ("report_id", "severity", "id");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_rule_id_idx" ON "issues" ("report_id", "rule_id", "id");
+
+--bun:split
+
+-- prefix searches use the index since LIKE is case sensitive on gitsast connections
+
+CREATE INDEX IF NOT EXISTS "issues_report_id_location_path_idx" ON "issues" ("report_id", "location_path");
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "issues_fingerprint_idx" ON "issues" ("fingerprint");
@@ -0,0 +1,5 @@
+ALTER TABLE "reports" DROP COLUMN "attempts";
+
+--bun:split
+
+ALTER TABLE "reports" DROP COLUMN "failure_kind";
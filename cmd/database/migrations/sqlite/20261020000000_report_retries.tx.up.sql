@@ -0,0 +1,7 @@
+-- sqlite adds a single column per statement
+
+ALTER TABLE "reports" ADD COLUMN "failure_kind" TEXT;
+
+--bun:split
+
+ALTER TABLE "reports" ADD COLUMN "attempts" INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+ALTER TABLE "finding_triage" ADD COLUMN "comments" TEXT;
+
+--bun:split
+
+UPDATE "finding_triage" AS t SET "comments" = (
+  SELECT json_group_array(json_object('author', c."author", 'body', c."body", 'created_at', strftime('%Y-%m-%dT%H:%M:%fZ', c."created_at")))
+  FROM (SELECT * FROM "triage_comments" ORDER BY "id") AS c
+  WHERE c."repository_id" = t."repository_id" AND c."fingerprint" = t."fingerprint"
+);
+
+--bun:split
+
+DROP TABLE IF EXISTS "triage_comments";
@@ -0,0 +1,32 @@
+-- comments were stored as a JSON array of their triage, concurrent comments overwrote each other
+
+CREATE TABLE IF NOT EXISTS "triage_comments" (
+  "id" INTEGER NOT NULL,
+  "repository_id" TEXT NOT NULL,
+  "fingerprint" TEXT NOT NULL,
+  "author" TEXT,
+  "body" TEXT,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("id")
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS "triage_comments_repository_id_fingerprint_idx" ON "triage_comments" ("repository_id", "fingerprint", "id");
+
+--bun:split
+
+INSERT INTO "triage_comments" ("repository_id", "fingerprint", "author", "body", "created_at")
+SELECT
+  t."repository_id",
+  t."fingerprint",
+  json_extract(c.value, '$.author'),
+  json_extract(c.value, '$.body'),
+  COALESCE(json_extract(c.value, '$.created_at'), t."updated_at")
+FROM "finding_triage" AS t,
+  json_each(CASE WHEN json_type(t."comments") = 'array' THEN t."comments" ELSE '[]' END) AS c
+ORDER BY t."repository_id", t."fingerprint", c.key;
+
+--bun:split
+
+ALTER TABLE "finding_triage" DROP COLUMN "comments";
@@ -0,0 +1,3 @@
+-- sqlite databases were never created with the legacy issues column of reports, see the postgres migration
+
+SELECT 1;
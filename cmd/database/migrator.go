@@ -3,47 +3,62 @@ package database
 import (
 	"context"
 	"database/sql"
+	"os"
+	"path/filepath"
 
+	"github.com/marktrs/gitsast/cmd/database/migrations"
 	"github.com/marktrs/gitsast/internal/model"
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/migrate"
 )
 
+// sqliteTemplate is the content of new sqlite migrations, the postgres template of bun
+// starts with a statement sqlite does not support
+const sqliteTemplate = `SELECT 1
+
+--bun:split
+
+SELECT 2
+`
+
 type dbMigrator struct {
-	migrations *migrate.Migrations
-	db         *bun.DB
-	models     []interface{}
+	migrator *migrate.Migrator
+	db       *bun.DB
+	models   []interface{}
 }
 
 func NewDBMigrator(db *bun.DB) *dbMigrator {
 	models := []interface{}{
 		(*model.Rule)(nil),
 		(*model.Report)(nil),
+		(*model.Issue)(nil),
 		(*model.Repository)(nil),
+		(*model.Triage)(nil),
+		(*model.TriageComment)(nil),
 	}
 
-	return &dbMigrator{
-		migrations: migrate.NewMigrations(),
-		db:         db,
-		models:     models,
+	m := &dbMigrator{db: db, models: models}
+
+	// migrations are only created without a database
+	if db != nil {
+		m.migrator = migrate.NewMigrator(db, migrations.ForDialect(db.Dialect().Name()), migrate.WithMarkAppliedOnSuccess(true))
 	}
+
+	return m
 }
 
-// Migrate - start db migration with list of models
-func (m *dbMigrator) Migrate() error {
+// Migrate - apply the pending migrations and insert the initial rules, existing data is kept
+func (m *dbMigrator) Migrate(ctx context.Context) error {
 	log.Info().Msg("starting db migration")
-	if err := m.DropTable(); err != nil {
-		log.Err(err)
-		return err
-	}
 
-	if err := m.CreateTablesIfNotExist(); err != nil {
+	if _, err := m.MigrateUp(ctx); err != nil {
 		log.Err(err)
 		return err
 	}
 
-	if err := m.InsertInitialRulesIfNotExist(); err != nil {
+	if err := m.InsertInitialRulesIfNotExist(ctx); err != nil {
 		log.Err(err)
 		return err
 	}
@@ -52,69 +67,110 @@ func (m *dbMigrator) Migrate() error {
 	return nil
 }
 
-// ResetTable - reset table
-func (m *dbMigrator) DropTable() error {
-	log.Info().Msg("resetting table")
+// MigrateUp - apply the pending migrations as a new migration group
+func (m *dbMigrator) MigrateUp(ctx context.Context) (*migrate.MigrationGroup, error) {
+	if err := m.migrator.Init(ctx); err != nil {
+		return nil, err
+	}
 
-	for _, model := range m.models {
-		_, err := m.db.NewDropTable().
-			Model(model).
-			IfExists().
-			Exec(context.Background())
-		if err != nil {
-			log.Err(err)
-			return err
+	if err := m.migrator.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.unlock(ctx)
+
+	return m.migrator.Migrate(ctx)
+}
+
+// MigrateDown - roll back the last migration group
+func (m *dbMigrator) MigrateDown(ctx context.Context) (*migrate.MigrationGroup, error) {
+	if err := m.migrator.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := m.migrator.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.unlock(ctx)
+
+	return m.migrator.Rollback(ctx)
+}
+
+// Status - list the migrations with the group they were applied in, zero when pending
+func (m *dbMigrator) Status(ctx context.Context) (migrate.MigrationSlice, error) {
+	if err := m.migrator.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.migrator.MigrationsWithStatus(ctx)
+}
+
+// Create - create the up and down SQL files of a new migration in the directory of every
+// dialect under dir, the files of a migration have the same name in every dialect
+func (m *dbMigrator) Create(ctx context.Context, dir string, name string) ([]*migrate.MigrationFile, error) {
+	pgDir := filepath.Join(dir, migrations.Dirs[dialect.PG])
+
+	migrator := migrate.NewMigrator(m.db, migrate.NewMigrations(migrate.WithMigrationsDirectory(pgDir)))
+	files, err := migrator.CreateSQLMigrations(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	sqliteDir := filepath.Join(dir, migrations.Dirs[dialect.SQLite])
+	sqliteFiles := make([]*migrate.MigrationFile, len(files))
+	for i, f := range files {
+		path := filepath.Join(sqliteDir, f.Name)
+		if err := os.WriteFile(path, []byte(sqliteTemplate), 0o644); err != nil {
+			return nil, err
 		}
+
+		sqliteFiles[i] = &migrate.MigrationFile{Name: f.Name, Path: path, Content: sqliteTemplate}
 	}
 
-	log.Info().Msg("reset table complete")
-	return nil
+	return append(files, sqliteFiles...), nil
 }
 
-// createTablesIfNotExist - Iterate through the list of model to create new tables if doesn't exist
-func (m *dbMigrator) CreateTablesIfNotExist() error {
-	log.Info().Msg("creating tables if not exist")
+func (m *dbMigrator) unlock(ctx context.Context) {
+	if err := m.migrator.Unlock(ctx); err != nil {
+		log.Err(err).Msg("unable to unlock migrations")
+	}
+}
+
+// DropTable - drop the tables of every model and forget the applied migrations
+func (m *dbMigrator) DropTable(ctx context.Context) error {
+	log.Info().Msg("resetting table")
+
 	for _, model := range m.models {
-		_, err := m.db.NewCreateTable().
+		_, err := m.db.NewDropTable().
 			Model(model).
-			IfNotExists().
-			Exec(context.Background())
+			IfExists().
+			Exec(ctx)
 		if err != nil {
 			log.Err(err)
 			return err
 		}
 	}
-	log.Info().Msg("created tables")
+
+	if err := m.migrator.Reset(ctx); err != nil {
+		log.Err(err)
+		return err
+	}
+
+	log.Info().Msg("reset table complete")
 	return nil
 }
 
-func (m *dbMigrator) InsertInitialRulesIfNotExist() error {
+func (m *dbMigrator) InsertInitialRulesIfNotExist(ctx context.Context) error {
 	log.Info().Msg("initializing rules")
-	rules := []*model.Rule{
-		{
-			ID:          1,
-			Name:        "Public key leak",
-			Description: "A secret starts with the prefix public_key",
-			Keyword:     "public_key",
-			Severity:    model.Low,
-		},
-		{
-			ID:          2,
-			Name:        "Private key leak",
-			Description: "A secret starts with the prefix private_key",
-			Keyword:     "private_key",
-			Severity:    model.High,
-		},
-	}
+	rules := model.DefaultRules()
 
 	keywords := make([]string, len(rules))
 	for i, rule := range rules {
 		keywords[i] = rule.Keyword
 	}
 
-	return m.db.RunInTx(context.Background(), &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+	return m.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
 		// check if exists
-		exists, err := m.db.NewSelect().
+		exists, err := tx.NewSelect().
 			Model((*model.Rule)(nil)).
 			Where("keyword IN (?)", bun.In(keywords)).
 			Exists(ctx)
@@ -128,9 +184,9 @@ func (m *dbMigrator) InsertInitialRulesIfNotExist() error {
 		}
 
 		// insert if not exists
-		_, err = m.db.NewInsert().
+		_, err = tx.NewInsert().
 			Model(&rules).
-			Exec(context.Background())
+			Exec(ctx)
 		if err != nil {
 			log.Err(err)
 			return err
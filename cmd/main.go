@@ -3,27 +3,44 @@ package main
 import (
 	"os"
 
+	gitsast "github.com/marktrs/gitsast/app"
 	"github.com/marktrs/gitsast/cmd/api"
+	"github.com/marktrs/gitsast/cmd/baseline"
+	"github.com/marktrs/gitsast/cmd/client"
 	"github.com/marktrs/gitsast/cmd/database"
+	"github.com/marktrs/gitsast/cmd/report"
+	"github.com/marktrs/gitsast/cmd/scan"
+	"github.com/marktrs/gitsast/cmd/worker"
 	_ "github.com/marktrs/gitsast/internal/model"
+	_ "github.com/marktrs/gitsast/internal/report"
 	_ "github.com/marktrs/gitsast/internal/repository"
+	_ "github.com/marktrs/gitsast/internal/triage"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
 	app := &cli.App{
-		Name: "GitSAST",
+		Name:    "GitSAST",
+		Version: gitsast.Version,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:  "env",
-				Value: "dev",
-				Usage: "environment",
+				Name:    "env",
+				Value:   "dev",
+				EnvVars: []string{"GITSAST_ENV"},
+				Usage:   "environment of the embedded config, such as dev or test",
 			},
 		},
 		Commands: []*cli.Command{
 			api.NewAPICommand(),
+			worker.NewWorkerCommand(),
 			database.NewDBCommand(),
+			scan.NewScanCommand(),
+			scan.NewProtectCommand(),
+			scan.NewInstallHookCommand(),
+			report.NewReportCommand(),
+			baseline.NewBaselineCommand(),
+			client.NewClientCommand(),
 		},
 	}
 	if err := app.Run(os.Args); err != nil {
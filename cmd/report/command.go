@@ -0,0 +1,196 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/cmd/cmdutil"
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/progress"
+	"github.com/marktrs/gitsast/internal/report"
+	"github.com/marktrs/gitsast/internal/repository"
+	"github.com/urfave/cli/v2"
+)
+
+func NewReportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "report",
+		Usage: "inspect and compare reports",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"GITSAST_CONFIG"},
+				Usage:   "path to a config file, the embedded config of --env is used when not set",
+			},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "get",
+				Usage: "export a report with its findings",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "report",
+						Usage:    "report ID",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: export.FormatJSON,
+						Usage: "output format (" + strings.Join(export.Formats(), ", ") + ")",
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Value: "-",
+						Usage: "path to write the report to, - for stdout",
+					},
+				},
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					e, err := export.Get(c.String("format"))
+					if err != nil {
+						return err
+					}
+
+					ctx, app, err := app.StartFromCLI(c)
+					if err != nil {
+						return err
+					}
+					defer app.Stop()
+
+					s := report.NewService(
+						app,
+						model.NewReportRepo(app),
+						model.NewRuleRepo(app),
+						model.NewTriageRepo(app),
+						progress.NewBroker(app),
+					)
+
+					if c.String("output") == "-" {
+						return s.Export(ctx, c.App.Writer, c.String("report"), nil, e)
+					}
+
+					f, err := os.Create(c.String("output"))
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+
+					if err := s.Export(ctx, f, c.String("report"), nil, e); err != nil {
+						return err
+					}
+
+					return f.Close()
+				}),
+			},
+			{
+				Name:  "check",
+				Usage: "print the summary and policy result of a report, exit with non-zero code if the policy failed",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "report",
+						Usage:    "report ID",
+						Required: true,
+					},
+				},
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					ctx, app, err := app.StartFromCLI(c)
+					if err != nil {
+						return err
+					}
+					defer app.Stop()
+
+					s := report.NewService(
+						app,
+						model.NewReportRepo(app),
+						model.NewRuleRepo(app),
+						model.NewTriageRepo(app),
+						progress.NewBroker(app),
+					)
+					summary, err := s.GetSummary(ctx, c.String("report"))
+					if err != nil {
+						return err
+					}
+
+					if err := cmdutil.PrintJSON(c, summary); err != nil {
+						return err
+					}
+
+					return cmdutil.CheckSummary(summary)
+				}),
+			},
+			{
+				Name:  "diff",
+				Usage: "classify findings of a report as new, fixed or unchanged compared to a base report",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "repository",
+						Usage:    "repository ID",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "report",
+						Usage:    "report ID",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "base",
+						Usage:    "base report ID to compare with",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "fail-on-new",
+						Usage: "exit with non-zero code if a new finding has at least this severity (LOW, MEDIUM, HIGH)",
+					},
+				},
+				Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+					ctx, app, err := app.StartFromCLI(c)
+					if err != nil {
+						return err
+					}
+					defer app.Stop()
+
+					s := repository.NewService(
+						app,
+						model.NewRepositoryRepo(app),
+						model.NewReportRepo(app),
+					)
+					diff, err := s.DiffReports(ctx, c.String("repository"), c.String("report"), c.String("base"))
+					if err != nil {
+						return err
+					}
+
+					if err := cmdutil.PrintJSON(c, diff); err != nil {
+						return err
+					}
+
+					if !c.IsSet("fail-on-new") {
+						return nil
+					}
+
+					return failOnNew(diff, c.String("fail-on-new"))
+				}),
+			},
+		},
+	}
+}
+
+// failOnNew - return an exit error if a new finding has at least the given severity
+func failOnNew(diff *repository.DiffReportResponse, severity string) error {
+	threshold, err := model.ParseScore(severity)
+	if err != nil {
+		return err
+	}
+
+	for s, count := range diff.Summary.NewBySeverity {
+		score, err := model.ParseScore(s)
+		if err != nil || score < threshold || count == 0 {
+			continue
+		}
+
+		return cli.Exit(fmt.Sprintf("found %d new %s finding(s)", count, s), cmdutil.ExitFailed)
+	}
+
+	return nil
+}
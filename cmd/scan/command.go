@@ -0,0 +1,225 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/cmd/cmdutil"
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// formatText is the default human readable output of the scan command
+const formatText = "text"
+
+func NewScanCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "scan",
+		Usage:     "scan a local directory or a git URL without the API server, database or queue",
+		ArgsUsage: "[directory or git URL]",
+		Description: "Scans the given directory, the current directory by default, or clones and scans a git URL.\n" +
+			"Exits with code 1 when the policy fails and 2 when the scan cannot be completed.",
+		Flags: append(findingFlags(),
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: app.DefaultConfig().Scan.Timeout,
+				Usage: "deadline of the whole scan, including cloning the repository",
+			},
+			&cli.DurationFlag{
+				Name:  "file-timeout",
+				Value: app.DefaultConfig().Scan.FileTimeout,
+				Usage: "time budget for scanning a single file",
+			},
+		),
+		Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+			if c.NArg() > 1 {
+				return errors.New("expected a single directory or git URL")
+			}
+
+			e, rules, policy, err := load(c)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			ctx, cancel := context.WithTimeout(ctx, c.Duration("timeout"))
+			defer cancel()
+
+			target := c.Args().First()
+			if target == "" {
+				target = "."
+			}
+
+			report, err := scan(ctx, target, rules, policy, c.Duration("file-timeout"))
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					return fmt.Errorf("%w: scan exceeded the deadline of %s", model.ErrScanTimeout, c.Duration("timeout"))
+				}
+				return err
+			}
+
+			return finish(c, report, rules, e)
+		}),
+	}
+}
+
+// findingFlags - flags of the commands finding issues without the API server
+func findingFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rules",
+			Usage: "path to a YAML or JSON rules file, the built-in rule pack by default",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Value: formatText,
+			Usage: "output format (" + strings.Join(append([]string{formatText}, export.Formats()...), ", ") + ")",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Value: "-",
+			Usage: "path to write the findings to, - for stdout",
+		},
+		&cli.StringFlag{
+			Name:  "policy",
+			Usage: "path to a YAML or JSON policy file, fails on any finding not in the baseline by default",
+		},
+		&cli.StringFlag{
+			Name:  "fail-on",
+			Usage: "fail when a finding not in the baseline has at least this severity (LOW, MEDIUM, HIGH), instead of the policy",
+		},
+	}
+}
+
+// load - load the exporter, the rules and the policy given by the command flags,
+// the exporter is nil for the text format
+func load(c *cli.Context) (export.Exporter, []*model.Rule, *app.Policy, error) {
+	var e export.Exporter
+	if format := c.String("format"); format != formatText {
+		var err error
+		if e, err = export.Get(format); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	rules, err := loadRules(c.String("rules"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	policy, err := loadPolicy(c.String("policy"), c.String("fail-on"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return e, rules, policy, nil
+}
+
+// finish - write the findings of a report and return an exit error when the policy failed
+func finish(c *cli.Context, report *model.Report, rules []*model.Rule, e export.Exporter) error {
+	if err := write(c, report, rules, e); err != nil {
+		return err
+	}
+
+	return cmdutil.CheckPolicy(report.PolicyResult)
+}
+
+// write - write the findings of a report in the given format, text without exporter
+func write(c *cli.Context, report *model.Report, rules []*model.Rule, e export.Exporter) error {
+	var w io.Writer = c.App.Writer
+	if c.String("output") != "-" {
+		f, err := os.Create(c.String("output"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if e == nil {
+		if err := writeText(w, report, rules); err != nil {
+			return err
+		}
+	} else if err := e.Export(w, report, rules); err != nil {
+		return err
+	}
+
+	if f, ok := w.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+
+	return nil
+}
+
+// loadRules - load the rules of a rules file, or the built-in rule pack
+func loadRules(path string) ([]*model.Rule, error) {
+	if path == "" {
+		return model.DefaultRules(), nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := model.ParseRules(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+// loadPolicy - load the policy of a policy file, or build it from the fail-on severity.
+// Without either, any finding not in the baseline fails the scan.
+func loadPolicy(path string, failOn string) (*app.Policy, error) {
+	if path != "" && failOn != "" {
+		return nil, errors.New("--policy and --fail-on cannot be used together")
+	}
+
+	if failOn != "" {
+		if _, err := model.ParseScore(failOn); err != nil {
+			return nil, err
+		}
+
+		return &app.Policy{Clauses: []*app.PolicyClause{{
+			Name:     "no new " + strings.ToUpper(failOn) + " or higher findings",
+			Severity: failOn,
+			NewOnly:  true,
+		}}}, nil
+	}
+
+	if path == "" {
+		return &app.Policy{Clauses: []*app.PolicyClause{{Name: "no new findings", NewOnly: true}}}, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML
+	var policy app.Policy
+	if err := yaml.Unmarshal(b, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// elapsed - the milliseconds since the given time
+func elapsed(since time.Time) int64 {
+	return time.Since(since).Milliseconds()
+}
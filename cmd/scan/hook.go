@@ -0,0 +1,90 @@
+package scan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// hookMarker identifies pre-commit hooks installed by gitsast, they are replaced without --force
+const hookMarker = "# installed by gitsast install-hook"
+
+func NewInstallHookCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "install-hook",
+		Usage: "install gitsast protect as the pre-commit hook of the current git repository",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "binary",
+				Usage: "path of the gitsast executable run by the hook, the current executable by default",
+			},
+			&cli.StringFlag{
+				Name:  "args",
+				Usage: "extra arguments of gitsast protect, such as \"--fail-on HIGH\"",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "replace an existing pre-commit hook not installed by gitsast",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			binary := c.String("binary")
+			if binary == "" {
+				var err error
+				if binary, err = os.Executable(); err != nil {
+					return err
+				}
+			}
+
+			// hooks may be moved by core.hooksPath
+			out, err := exec.CommandContext(c.Context, "git", "rev-parse", "--git-path", "hooks").Output()
+			if err != nil {
+				return fmt.Errorf("unable to locate git hooks, not a git repository: %w", err)
+			}
+
+			hooksDir := strings.TrimSpace(string(out))
+			if err := os.MkdirAll(hooksDir, 0755); err != nil {
+				return err
+			}
+
+			path := filepath.Join(hooksDir, "pre-commit")
+			existing, err := os.ReadFile(path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			if len(existing) > 0 && !bytes.Contains(existing, []byte(hookMarker)) && !c.Bool("force") {
+				return fmt.Errorf("%s already exists, use --force to replace it", path)
+			}
+
+			if err := os.WriteFile(path, []byte(preCommitHook(binary, c.String("args"))), 0755); err != nil {
+				return err
+			}
+
+			// the mode of an existing file is kept by WriteFile
+			if err := os.Chmod(path, 0755); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.App.Writer, "installed pre-commit hook %s\n", path)
+			return nil
+		},
+	}
+}
+
+// preCommitHook - the pre-commit hook script running gitsast protect, the commit is aborted
+// when protect exits with a non-zero code
+func preCommitHook(binary string, args string) string {
+	command := "'" + strings.ReplaceAll(binary, "'", `'\''`) + "' protect"
+	if args != "" {
+		command += " " + args
+	}
+
+	return "#!/bin/sh\n" + hookMarker + "\nexec " + command + "\n"
+}
@@ -0,0 +1,105 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/cmd/cmdutil"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/queue/task/analyzer"
+	"github.com/marktrs/gitsast/internal/queue/task/analyzer/git"
+	"github.com/urfave/cli/v2"
+)
+
+func NewProtectCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "protect",
+		Usage: "scan the lines added by the staged changes of the current git repository, before they are committed",
+		Description: "Only added lines of staged files are scanned, findings are located on the lines of the staged files.\n" +
+			"Exits with code 1 when the policy fails and 2 when the scan cannot be completed.",
+		Flags: findingFlags(),
+		Action: cmdutil.WithExitCode(func(c *cli.Context) error {
+			e, rules, policy, err := load(c)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			report, err := protect(ctx, dir, rules, policy)
+			if err != nil {
+				return err
+			}
+
+			return finish(c, report, rules, e)
+		}),
+	}
+}
+
+// protect - scan the lines added by the staged changes of the git repository containing dir
+func protect(ctx context.Context, dir string, rules []*model.Rule, policy *app.Policy) (*model.Report, error) {
+	report := &model.Report{
+		ID:        uuid.NewString(),
+		Status:    model.StatusInProgress,
+		StartedAt: time.Now(),
+	}
+
+	summary := model.NewSummary()
+	report.Summary = summary
+
+	root, changes, err := git.GetStagedChanges(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	scanStart := time.Now()
+	scanner := analyzer.NewScanner(analyzer.NewDetector(), 0)
+
+	issues := make([]*model.Issue, 0)
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, line := range change.Lines {
+			summary.BytesScanned += int64(len(line))
+		}
+
+		issues = append(issues, scanner.ScanLineForIssues(analyzer.Fragment{
+			Raw:      change.Content(),
+			FilePath: "/" + change.Path,
+		}, rules)...)
+	}
+
+	summary.FilesDiscovered = len(changes)
+	summary.FilesScanned = len(changes)
+	summary.ScanDurationMS = elapsed(scanStart)
+
+	issues = model.DedupeIssues(issues)
+	sortIssues(issues)
+
+	model.ApplyBaselines(issues, readBaselineFile(root))
+	summary.CountIssues(issues)
+
+	report.PolicyResult, err = model.EvaluatePolicy(policy, model.PolicySourceGlobal, issues)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Issues = issues
+	report.Status = model.StatusSuccess
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
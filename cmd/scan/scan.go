@@ -0,0 +1,209 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/queue/task/analyzer"
+	"github.com/marktrs/gitsast/internal/queue/task/analyzer/git"
+	"github.com/rs/zerolog/log"
+)
+
+// scan - scan a local directory or clone and scan a git URL, the report is kept in memory
+func scan(
+	ctx context.Context,
+	target string,
+	rules []*model.Rule,
+	policy *app.Policy,
+	fileTimeout time.Duration,
+) (*model.Report, error) {
+	report := &model.Report{
+		ID:        uuid.NewString(),
+		Status:    model.StatusInProgress,
+		StartedAt: time.Now(),
+	}
+
+	summary := model.NewSummary()
+	report.Summary = summary
+
+	cloneStart := time.Now()
+	root, checkout, cleanup, err := checkoutTarget(ctx, target)
+	summary.CloneDurationMS = elapsed(cloneStart)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	summary.CommitSHA = checkout.CommitSHA
+	summary.FilesDiscovered = len(checkout.Paths) + checkout.Ignored
+	summary.Skip(model.SkipIgnoredFileType, checkout.Ignored)
+
+	scanStart := time.Now()
+	scanner := analyzer.NewScanner(analyzer.NewDetector(), fileTimeout)
+	issues, stats, err := scanner.ScanFilesForIssues(ctx, root, checkout.Paths, rules, nil)
+	summary.ScanDurationMS = elapsed(scanStart)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.FilesScanned = stats.FilesScanned
+	summary.BytesScanned = stats.BytesScanned
+	for reason, files := range stats.FilesSkipped {
+		summary.Skip(reason, files)
+	}
+
+	issues = model.DedupeIssues(issues)
+	sortIssues(issues)
+
+	if checkout.CommitSHA != "" && len(issues) > 0 {
+		if err := git.NewClient().BlameIssues(ctx, root, issues); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Warn().Err(err).Msg("unable to attribute issues to commits")
+		}
+	}
+
+	model.ApplyBaselines(issues, readBaselineFile(root))
+	summary.CountIssues(issues)
+
+	report.PolicyResult, err = model.EvaluatePolicy(policy, model.PolicySourceGlobal, issues)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Issues = issues
+	report.Status = model.StatusSuccess
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
+
+// checkoutTarget - list the files of a local directory, or clone a git URL into a temporary
+// directory removed by the returned cleanup function
+func checkoutTarget(ctx context.Context, target string) (string, *git.Checkout, func(), error) {
+	if info, err := os.Stat(target); err == nil {
+		if !info.IsDir() {
+			return "", nil, nil, fmt.Errorf("%s is not a directory", target)
+		}
+
+		root, err := filepath.Abs(target)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		checkout, err := git.GetPathsFromDir(root)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		return root, checkout, func() {}, nil
+	} else if !isGitURL(target) {
+		return "", nil, nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gitsast-")
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	cleanup := func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Err(err).Msg("unable to remove cloned repository")
+		}
+	}
+
+	root := filepath.Join(tmpDir, "repository")
+	checkout, err := git.NewClient().GetPathsFromRemoteURL(ctx, root, target, nil)
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+
+	return root, checkout, cleanup, nil
+}
+
+// isGitURL - check if the target looks like a remote git URL rather than a local path
+func isGitURL(target string) bool {
+	return strings.Contains(target, "://") || strings.HasPrefix(target, "git@")
+}
+
+// readBaselineFile - read the baseline file committed at the root of the scanned directory
+func readBaselineFile(root string) *model.Baseline {
+	b, err := os.ReadFile(filepath.Join(root, model.BaselineFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Err(err).Msg("unable to read baseline file")
+		}
+		return nil
+	}
+
+	baseline, err := model.ParseBaseline(b)
+	if err != nil {
+		log.Err(err).Msg("unable to parse baseline file")
+		return nil
+	}
+
+	return baseline
+}
+
+// sortIssues - order issues by path, line and rule, files are scanned concurrently
+func sortIssues(issues []*model.Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Location.Path != b.Location.Path {
+			return a.Location.Path < b.Location.Path
+		}
+		if a.Location.Line != b.Location.Line {
+			return a.Location.Line < b.Location.Line
+		}
+		return a.RuleID < b.RuleID
+	})
+}
+
+// writeText - write findings, the summary and the policy result for humans
+func writeText(w io.Writer, report *model.Report, rules []*model.Rule) error {
+	names := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		names[model.GetFormattedRuleId(rule.ID)] = rule.Name
+	}
+
+	var b strings.Builder
+	for _, issue := range report.Issues {
+		fmt.Fprintf(&b, "%-6s %s %s:%d", issue.Severity, issue.RuleID,
+			strings.TrimPrefix(issue.Location.Path, "/"), issue.Location.Line+1)
+		if name := names[issue.RuleID]; name != "" {
+			fmt.Fprintf(&b, " %s", name)
+		}
+		if issue.Status == model.IssueStatusBaselined {
+			b.WriteString(" (baselined)")
+		}
+		b.WriteString("\n")
+		if issue.Snippet != "" {
+			fmt.Fprintf(&b, "       %s\n", issue.Snippet)
+		}
+	}
+
+	s := report.Summary
+	fmt.Fprintf(&b, "\n%d finding(s), %d file(s) scanned, %d skipped, %d bytes in %dms\n",
+		s.TotalIssues, s.FilesScanned, s.FilesSkipped, s.BytesScanned, s.CloneDurationMS+s.ScanDurationMS)
+
+	if r := report.PolicyResult; r != nil {
+		fmt.Fprintf(&b, "policy: %s\n", r.Status)
+		for _, v := range r.Violations {
+			fmt.Fprintf(&b, "  %s: %d finding(s), %d allowed\n", v.Clause, v.Count, v.MaxCount)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
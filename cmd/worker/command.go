@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func NewWorkerCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "worker",
+		Usage: "consume the scan queue without serving the API",
+		Description: "Workers scale apart from the API servers started with api --worker=false.\n" +
+			"On SIGTERM a worker stops taking scans and waits for running scans up to the shutdown timeout,\n" +
+			"scans still running afterwards are interrupted and re-queued.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"GITSAST_CONFIG"},
+				Usage:   "path to a config file, the embedded config of --env is used when not set",
+			},
+		}, Flags()...),
+		Action: func(c *cli.Context) error {
+			ctx, worker, err := app.StartFromCLI(c)
+			if err != nil {
+				return err
+			}
+			defer worker.Stop()
+
+			if worker.Config().Queue.IsMemory() {
+				return errors.New("scans queued in memory are only run by the api command, configure the redis queue backend to run workers")
+			}
+
+			if err := Start(ctx, c, worker); err != nil {
+				return err
+			}
+
+			sig := app.WaitExitSignal()
+			log.Info().Any("signal", sig).Msg("stopping worker")
+
+			return Stop(c, worker)
+		},
+	}
+}
+
+// Flags - flags of the commands consuming the queue, they override the worker config
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of scans run at the same time (default: worker.concurrency of the config)",
+		},
+		&cli.DurationFlag{
+			Name:  "shutdown-timeout",
+			Usage: "how long running scans are waited for on shutdown (default: worker.shutdown_timeout of the config)",
+		},
+	}
+}
+
+// Start - start consuming the queue, scans run with the given context of the app
+func Start(ctx context.Context, c *cli.Context, app *app.App) error {
+	concurrency := app.Config().Worker.GetConcurrency()
+	if c.IsSet("concurrency") {
+		concurrency = c.Int("concurrency")
+	}
+
+	if err := app.Queue().StartConsumer(ctx, concurrency); err != nil {
+		return err
+	}
+
+	log.Info().Int("concurrency", concurrency).Msg("started queue consumer")
+	return nil
+}
+
+// Stop - stop consuming the queue, running scans are waited for up to the shutdown timeout
+func Stop(c *cli.Context, app *app.App) error {
+	timeout := app.Config().Worker.GetShutdownTimeout()
+	if c.IsSet("shutdown-timeout") {
+		timeout = c.Duration("shutdown-timeout")
+	}
+
+	return app.Queue().StopConsumer(timeout)
+}
@@ -1,10 +1,14 @@
 #!/bin/sh
-APP_ENV=${APP_ENV:-local}
+APP_ENV=${APP_ENV:-dev}
 
 echo "[`date`] Running entrypoint script in the '${APP_ENV}' environment..."
 
-CONFIG_FILE=/app/config/${APP_ENV}.yaml
+# a mounted config file takes precedence over the config embedded in the binary,
+# either can be overridden with GITSAST_* environment variables
+CONFIG_FILE=${GITSAST_CONFIG:-/app/config/${APP_ENV}.yaml}
+if [ ! -f "${CONFIG_FILE}" ]; then
+  CONFIG_FILE=""
+fi
 
 echo "[`date`] Starting server..."
-/app/bin/server --config ${CONFIG_FILE}
-
+GITSAST_CONFIG=${CONFIG_FILE} exec /app/bin/server --env ${APP_ENV} api
@@ -0,0 +1,258 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/report"
+	"github.com/marktrs/gitsast/internal/repository"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultURL is the base URL of a gitsast API running locally with the default config
+const DefaultURL = "http://127.0.0.1:8080"
+
+// Config holds the connection settings of a client, read from the client config file
+type Config struct {
+	// URL is the base URL of the gitsast API, without the /api/v1 prefix
+	URL string `yaml:"url"`
+	// Token is sent as a bearer token, for APIs behind an authenticating proxy
+	Token string `yaml:"token"`
+	// Timeout is the deadline of each API request, event streams are not limited
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// LoadConfig - read a client config file
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// APIError is an error response of the API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Client is a client of the gitsast API
+type Client struct {
+	baseURL string
+	token   string
+	timeout time.Duration
+	http    *http.Client
+}
+
+func New(cfg *Config) *Client {
+	baseURL := cfg.URL
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1",
+		token:   cfg.Token,
+		timeout: cfg.Timeout,
+		http:    &http.Client{},
+	}
+}
+
+// AddRepository - add a repository
+func (c *Client) AddRepository(ctx context.Context, r *repository.AddRepositoryRequest) (*model.Repository, error) {
+	var repo model.Repository
+	if err := c.do(ctx, http.MethodPost, "/repository", nil, r, &repo); err != nil {
+		return nil, err
+	}
+
+	return &repo, nil
+}
+
+// ListRepositories - list a page of repositories
+func (c *Client) ListRepositories(ctx context.Context, limit, offset int) ([]*model.Repository, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+
+	var response struct {
+		Repositories []*model.Repository `json:"repositories"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/repository", query, nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Repositories, nil
+}
+
+// GetRepository - get a repository by ID
+func (c *Client) GetRepository(ctx context.Context, id string) (*model.Repository, error) {
+	var repo model.Repository
+	if err := c.do(ctx, http.MethodGet, "/repository/"+url.PathEscape(id), nil, nil, &repo); err != nil {
+		return nil, err
+	}
+
+	return &repo, nil
+}
+
+// RemoveRepository - remove a repository by ID
+func (c *Client) RemoveRepository(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/repository/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// StartScan - enqueue a scan of a repository, the options override the repository defaults when given
+func (c *Client) StartScan(ctx context.Context, id string, r *repository.ScanRequest) (*model.Report, error) {
+	var rep model.Report
+	if err := c.do(ctx, http.MethodPost, "/repository/"+url.PathEscape(id)+"/scan", nil, r, &rep); err != nil {
+		return nil, err
+	}
+
+	return &rep, nil
+}
+
+// CancelScan - cancel the queued or running scan of a repository
+func (c *Client) CancelScan(ctx context.Context, id string) (*model.Report, error) {
+	var rep model.Report
+	if err := c.do(ctx, http.MethodPost, "/repository/"+url.PathEscape(id)+"/scan/cancel", nil, nil, &rep); err != nil {
+		return nil, err
+	}
+
+	return &rep, nil
+}
+
+// GetSummary - get the status, summary and policy result of a report
+func (c *Client) GetSummary(ctx context.Context, id string) (*report.SummaryResponse, error) {
+	var summary report.SummaryResponse
+	if err := c.do(ctx, http.MethodGet, "/reports/"+url.PathEscape(id)+"/summary", nil, nil, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// ExportReport - write a report in the given export format
+func (c *Client) ExportReport(ctx context.Context, w io.Writer, id string, format string) error {
+	query := url.Values{}
+	query.Set("format", format)
+
+	var buf bytes.Buffer
+	if err := c.do(ctx, http.MethodGet, "/reports/"+url.PathEscape(id), query, nil, &buf); err != nil {
+		return err
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// do - send a request with an optional JSON body, decode the JSON response into out
+// or copy it when out is a buffer. Error responses are returned as *APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	resp, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch out := out.(type) {
+	case nil:
+		_, err = io.Copy(io.Discard, resp.Body)
+	case *bytes.Buffer:
+		_, err = out.ReadFrom(resp.Body)
+	default:
+		err = json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	return err
+}
+
+// send - send a request and check its status, the caller closes the response body
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+
+	return resp, nil
+}
+
+// decodeError - decode an error response, falling back to the raw body for errors
+// not sent by the API such as those of a proxy
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return errors.Join(apiErr, err)
+	}
+
+	if err := json.Unmarshal(b, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(b))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+
+	return apiErr
+}
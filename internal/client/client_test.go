@@ -0,0 +1,183 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/client"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(t *testing.T, h http.HandlerFunc) *client.Client {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	return client.New(&client.Config{URL: srv.URL + "/", Token: "secret", Timeout: time.Second})
+}
+
+func TestAddRepository(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/repository", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req repository.AddRepositoryRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "gitsast", req.Name)
+
+		_ = json.NewEncoder(w).Encode(&model.Repository{ID: "repo-1", Name: req.Name, RemoteURL: req.RemoteURL})
+	})
+
+	repo, err := c.AddRepository(context.Background(), &repository.AddRepositoryRequest{
+		Name:      "gitsast",
+		RemoteURL: "https://github.com/marktrs/gitsast.git",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "repo-1", repo.ID)
+}
+
+func TestListRepositories(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		assert.Equal(t, "20", r.URL.Query().Get("offset"))
+
+		fmt.Fprint(w, `{"repositories":[{"id":"repo-1"},{"id":"repo-2"}],"total":2}`)
+	})
+
+	repos, err := c.ListRepositories(context.Background(), 10, 20)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+}
+
+func TestAPIError(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":"not_found","message":"Row not found"}`)
+	})
+
+	_, err := c.GetRepository(context.Background(), "missing")
+
+	var apiErr *client.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "not_found", apiErr.Code)
+	assert.Equal(t, "api: 404 not_found: Row not found", err.Error())
+}
+
+func TestAPIErrorWithoutJSON(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	err := c.RemoveRepository(context.Background(), "repo-1")
+
+	var apiErr *client.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Bad Gateway", apiErr.Message)
+}
+
+func TestExportReport(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/reports/report-1", r.URL.Path)
+		assert.Equal(t, "sarif", r.URL.Query().Get("format"))
+
+		fmt.Fprint(w, `{"version":"2.1.0"}`)
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.ExportReport(context.Background(), &buf, "report-1", "sarif"))
+	assert.Equal(t, `{"version":"2.1.0"}`, buf.String())
+}
+
+func TestWaitEvents(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/reports/report-1/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, ": keep-alive\n\n"+
+				"event: progress\ndata: {\"stage\":\"scanning\",\"percent\":50}\n\n"+
+				"event: progress\ndata: {\"stage\":\"finished\",\"percent\":100}\n\n")
+		case "/api/v1/reports/report-1/summary":
+			fmt.Fprint(w, `{"report_id":"report-1","status":"success","policy_result":{"status":"fail"}}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	stages := make([]model.ProgressStage, 0)
+	summary, err := c.Wait(context.Background(), "report-1", &client.WaitOptions{
+		OnProgress: func(p *model.Progress) {
+			stages = append(stages, p.Stage)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ProgressStage{model.ProgressScanning, model.ProgressFinished}, stages)
+	assert.Equal(t, model.StatusSuccess, summary.Status)
+	assert.True(t, summary.PolicyResult.Failed())
+}
+
+func TestWaitPoll(t *testing.T) {
+	var polls int32
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/reports/report-1/summary", r.URL.Path)
+
+		status := model.StatusInProgress
+		if atomic.AddInt32(&polls, 1) == 3 {
+			status = model.StatusFailed
+		}
+		fmt.Fprintf(w, `{"report_id":"report-1","status":%q}`, status)
+	})
+
+	summary, err := c.Wait(context.Background(), "report-1", &client.WaitOptions{
+		Interval: time.Millisecond,
+		Poll:     true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, summary.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+}
+
+func TestWaitStreamClosed(t *testing.T) {
+	var polls int32
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/reports/report-1/events":
+			// the stream drops before the scan is done
+			_, _ = io.WriteString(w, "data: {\"stage\":\"cloning\"}\n\n")
+		case "/api/v1/reports/report-1/summary":
+			status := model.StatusInProgress
+			if atomic.AddInt32(&polls, 1) == 2 {
+				status = model.StatusCancelled
+			}
+			fmt.Fprintf(w, `{"report_id":"report-1","status":%q}`, status)
+		}
+	})
+
+	summary, err := c.Wait(context.Background(), "report-1", &client.WaitOptions{Interval: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusCancelled, summary.Status)
+}
+
+func TestWaitNotFound(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":"not_found","message":"Row not found"}`)
+	})
+
+	_, err := c.Wait(context.Background(), "missing", &client.WaitOptions{})
+
+	var apiErr *client.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
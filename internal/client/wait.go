@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/report"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrStreamClosed is returned when an event stream ends before the scan is done
+var ErrStreamClosed = errors.New("event stream closed before the scan was done")
+
+// WaitOptions configures how Wait follows a scan
+type WaitOptions struct {
+	// Interval is the delay between polls of the report summary
+	Interval time.Duration
+	// Poll disables the event stream, the report summary is polled only
+	Poll bool
+	// OnProgress is called on every progress event, events are not received when polling
+	OnProgress func(*model.Progress)
+}
+
+// Wait - wait until the scan of a report is finished and return its summary. The progress
+// is followed through the event stream of the report, the summary is polled when the stream
+// is disabled or drops.
+func (c *Client) Wait(ctx context.Context, id string, opts *WaitOptions) (*report.SummaryResponse, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	for {
+		if !opts.Poll {
+			err := c.Events(ctx, id, opts.OnProgress)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			// an unknown report is not going to show up by polling
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return nil, err
+			}
+			if err != nil {
+				log.Debug().Err(err).Str("report_id", id).Msg("event stream interrupted, polling report summary")
+			}
+		}
+
+		summary, err := c.GetSummary(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if summary.Status.IsFinished() {
+			return summary, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Events - stream the progress events of a report until the scan is done,
+// ErrStreamClosed is returned when the stream ends earlier
+func (c *Client) Events(ctx context.Context, id string, fn func(*model.Progress)) error {
+	resp, err := c.send(ctx, http.MethodGet, "/reports/"+url.PathEscape(id)+"/events", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var data strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			// a blank line dispatches the event
+			if data.Len() == 0 {
+				continue
+			}
+
+			var p model.Progress
+			if err := json.Unmarshal([]byte(data.String()), &p); err != nil {
+				return err
+			}
+			data.Reset()
+
+			if fn != nil {
+				fn(&p)
+			}
+
+			if p.Stage.IsDone() {
+				return nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteString("\n")
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// comments and other fields, such as the event name, are ignored
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return ErrStreamClosed
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/marktrs/gitsast/internal/model"
+)
+
+var csvHeader = []string{
+	"rule_id",
+	"rule_name",
+	"severity",
+	"path",
+	"line",
+	"end_line",
+	"description",
+	"fingerprint",
+	"status",
+	"triage",
+	"commit_sha",
+	"commit_author",
+	"snippet",
+}
+
+// csvExporter exports the issues of a report as CSV rows, one per issue, for spreadsheets
+type csvExporter struct{}
+
+func (e *csvExporter) Format() string      { return FormatCSV }
+func (e *csvExporter) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (e *csvExporter) Export(w io.Writer, report *model.Report, rules []*model.Rule) error {
+	names := ruleNames(rules)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, issue := range report.Issues {
+		var triage, commitSHA, commitAuthor string
+		if issue.Triage != nil {
+			triage = string(issue.Triage.State)
+		}
+		if issue.Commit != nil {
+			commitSHA = issue.Commit.SHA
+			commitAuthor = issue.Commit.AuthorName
+		}
+
+		line, endLine := issueLines(issue)
+
+		row := []string{
+			issue.RuleID,
+			names[issue.RuleID],
+			issue.Severity,
+			strings.TrimPrefix(issue.Location.Path, "/"),
+			strconv.Itoa(line),
+			strconv.Itoa(endLine),
+			issue.Description,
+			issue.Fingerprint,
+			string(issue.Status),
+			triage,
+			commitSHA,
+			commitAuthor,
+			issue.Snippet,
+		}
+		for i, cell := range row {
+			row[i] = csvCell(cell)
+		}
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell - escape cells that spreadsheets would evaluate as formulas,
+// snippets and paths come from scanned repositories and cannot be trusted
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ruleNames - map formatted rule IDs to rule names
+func ruleNames(rules []*model.Rule) map[string]string {
+	names := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		names[model.GetFormattedRuleId(rule.ID)] = rule.Name
+	}
+	return names
+}
+
+// issueLines - get the 1-based first and last lines of an issue
+func issueLines(issue *model.Issue) (int, int) {
+	line := int(issue.Location.Line) + 1
+	if issue.Location.EndLine > issue.Location.Line {
+		return line, int(issue.Location.EndLine) + 1
+	}
+	return line, line
+}
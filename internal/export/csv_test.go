@@ -0,0 +1,54 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVExporter(t *testing.T) {
+	e, err := export.Get(export.FormatCSV)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, &model.Report{
+		Issues: []*model.Issue{
+			{
+				RuleID:      "G002",
+				Location:    model.Location{Path: "/config/priv.key", Line: 4, EndLine: 5},
+				Description: "Private key leak, found",
+				Severity:    "HIGH",
+				Fingerprint: "active",
+				Status:      model.IssueStatusActive,
+				Snippet:     `=HYPERLINK("http://example.com")`,
+				Triage:      &model.IssueTriage{State: model.TriageConfirmed},
+				Commit:      &model.Commit{SHA: "8f1d5a3", AuthorName: "Jane Doe"},
+			},
+		},
+	}, []*model.Rule{{ID: 2, Name: "Private key leak"}}))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "rule_id", records[0][0])
+	assert.Equal(t, []string{
+		"G002",
+		"Private key leak",
+		"HIGH",
+		"config/priv.key",
+		"5",
+		"6",
+		"Private key leak, found",
+		"active",
+		"active",
+		"confirmed",
+		"8f1d5a3",
+		"Jane Doe",
+		// formulas are escaped for spreadsheets
+		`'=HYPERLINK("http://example.com")`,
+	}, records[1])
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marktrs/gitsast/internal/model"
+)
+
+// report formats supported by the format query param and CLI flag
+const (
+	FormatJSON                  = "json"
+	FormatSARIF                 = "sarif"
+	FormatGitLabSecretDetection = "gitlab-secret-detection"
+	FormatGitLabSAST            = "gitlab-sast"
+	FormatHTML                  = "html"
+	FormatCSV                   = "csv"
+	FormatJUnit                 = "junit"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported report format")
+
+// Exporter writes a report in a given format. The issues of the report are the
+// ones to export, with their triage state already carried forward.
+type Exporter interface {
+	// Format is the name of the format selected by the format query param or CLI flag
+	Format() string
+	// ContentType is the media type of the exported document, matched against Accept headers
+	ContentType() string
+	Export(w io.Writer, report *model.Report, rules []*model.Rule) error
+}
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = make(map[string]Exporter)
+	// exporterOrder keeps the registration order, the first exporter of a media type wins
+	exporterOrder []Exporter
+)
+
+func init() {
+	Register(&jsonExporter{})
+	Register(&sarifExporter{})
+	Register(&gitLabExporter{format: FormatGitLabSecretDetection, reportType: GitLabSecretDetection})
+	Register(&gitLabExporter{format: FormatGitLabSAST, reportType: GitLabSAST})
+	Register(&htmlExporter{})
+	Register(&csvExporter{})
+	Register(&junitExporter{})
+}
+
+// Register - make an exporter available by its format, it panics if the format is already registered
+func Register(e Exporter) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+
+	if _, ok := exporters[e.Format()]; ok {
+		panic(fmt.Sprintf("export: format %q is already registered", e.Format()))
+	}
+
+	exporters[e.Format()] = e
+	exporterOrder = append(exporterOrder, e)
+}
+
+// Get - get the exporter of a format
+func Get(format string) (Exporter, error) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	e, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	return e, nil
+}
+
+// Formats - list the registered formats in alphabetical order
+func Formats() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// Negotiate - get the exporter of the first media type of an Accept header having one,
+// wildcards select the JSON exporter
+func Negotiate(accept string) (Exporter, bool) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		if mediaType == "*/*" || mediaType == "application/*" {
+			return exporters[FormatJSON], true
+		}
+
+		for _, e := range exporterOrder {
+			contentType, _, _ := mime.ParseMediaType(e.ContentType())
+			if contentType == mediaType {
+				return e, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// writeJSON - write an indented JSON document
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// jsonExporter exports a report as the JSON report response of the API
+type jsonExporter struct{}
+
+func (e *jsonExporter) Format() string      { return FormatJSON }
+func (e *jsonExporter) ContentType() string { return "application/json" }
+
+func (e *jsonExporter) Export(w io.Writer, report *model.Report, _ []*model.Rule) error {
+	var response model.ReportResponse
+	response.Report = *report
+	response.Issues = nil
+	response.Findings = model.NewFindings(report.Issues)
+
+	return writeJSON(w, &response)
+}
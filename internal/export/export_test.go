@@ -0,0 +1,98 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExporter struct{}
+
+func (e *fakeExporter) Format() string      { return "fake" }
+func (e *fakeExporter) ContentType() string { return "application/x-fake" }
+
+func (e *fakeExporter) Export(w io.Writer, report *model.Report, _ []*model.Rule) error {
+	_, err := io.WriteString(w, report.ID)
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	export.Register(&fakeExporter{})
+
+	e, err := export.Get("fake")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, &model.Report{ID: "report-uuid"}, nil))
+	assert.Equal(t, "report-uuid", buf.String())
+
+	e, ok := export.Negotiate("application/x-fake")
+	assert.True(t, ok)
+	assert.Equal(t, "fake", e.Format())
+
+	assert.Panics(t, func() { export.Register(&fakeExporter{}) })
+}
+
+func TestGet(t *testing.T) {
+	for _, format := range []string{"json", "sarif", "gitlab-secret-detection", "gitlab-sast", "html", "csv", "junit"} {
+		e, err := export.Get(format)
+		assert.NoError(t, err)
+		assert.Equal(t, format, e.Format())
+		assert.Contains(t, export.Formats(), format)
+	}
+
+	_, err := export.Get("pdf")
+	assert.ErrorIs(t, err, export.ErrUnsupportedFormat)
+}
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		accept string
+		format string
+		ok     bool
+	}{
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", format: "html", ok: true},
+		{accept: "text/csv", format: "csv", ok: true},
+		{accept: "application/xml", format: "junit", ok: true},
+		{accept: "application/sarif+json", format: "sarif", ok: true},
+		{accept: "application/json", format: "json", ok: true},
+		{accept: "image/png, */*", format: "json", ok: true},
+		{accept: "image/png", ok: false},
+		{accept: "", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			e, ok := export.Negotiate(tc.accept)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.format, e.Format())
+			}
+		})
+	}
+}
+
+func TestJSONExporter(t *testing.T) {
+	e, err := export.Get(export.FormatJSON)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, &model.Report{
+		ID: "report-uuid",
+		Issues: []*model.Issue{
+			{RuleID: "G001", Fingerprint: "triaged", Triage: &model.IssueTriage{State: model.TriageConfirmed}},
+		},
+	}, nil))
+
+	var response model.ReportResponse
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &response))
+	assert.Equal(t, "report-uuid", response.ID)
+	assert.Nil(t, response.Issues)
+	assert.Len(t, response.Findings, 1)
+	assert.Equal(t, model.TriageConfirmed, response.Findings[0].Triage.State)
+}
@@ -0,0 +1,232 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+)
+
+// GitLabReportType is the type of a GitLab security report
+type GitLabReportType string
+
+const (
+	// GitLabSecretDetection is the report type of gl-secret-detection-report.json
+	GitLabSecretDetection GitLabReportType = "secret_detection"
+	// GitLabSAST is the report type of gl-sast-report.json
+	GitLabSAST GitLabReportType = "sast"
+
+	// GitLabReportVersion is the version of the GitLab security report schemas exported reports comply with
+	GitLabReportVersion = "15.0.6"
+
+	// gitLabTimeLayout is the UTC time format of the scan, without time zone
+	gitLabTimeLayout = "2006-01-02T15:04:05"
+	// gitLabUnknownCommit is used when the commit that introduced a secret is unknown
+	gitLabUnknownCommit = "0000000"
+)
+
+// GitLabReport is a GitLab security report holding the issues of a single report
+type GitLabReport struct {
+	Version         string                 `json:"version"`
+	Vulnerabilities []*GitLabVulnerability `json:"vulnerabilities"`
+	Scan            GitLabScan             `json:"scan"`
+}
+
+type GitLabScan struct {
+	Analyzer  GitLabTool       `json:"analyzer"`
+	Scanner   GitLabTool       `json:"scanner"`
+	Type      GitLabReportType `json:"type"`
+	StartTime string           `json:"start_time"`
+	EndTime   string           `json:"end_time"`
+	Status    string           `json:"status"`
+}
+
+type GitLabTool struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	URL     string       `json:"url,omitempty"`
+	Version string       `json:"version"`
+	Vendor  GitLabVendor `json:"vendor"`
+}
+
+type GitLabVendor struct {
+	Name string `json:"name"`
+}
+
+type GitLabVulnerability struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Severity    string              `json:"severity"`
+	Identifiers []*GitLabIdentifier `json:"identifiers"`
+	Location    GitLabLocation      `json:"location"`
+	Flags       []*GitLabFlag       `json:"flags,omitempty"`
+}
+
+type GitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GitLabLocation is the location of a vulnerability, lines start at 1
+type GitLabLocation struct {
+	File      string        `json:"file"`
+	StartLine int           `json:"start_line"`
+	EndLine   int           `json:"end_line,omitempty"`
+	Commit    *GitLabCommit `json:"commit,omitempty"`
+}
+
+type GitLabCommit struct {
+	SHA    string `json:"sha"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+}
+
+// GitLabFlag marks a vulnerability, GitLab only supports flagging likely false positives
+type GitLabFlag struct {
+	Type        string `json:"type"`
+	Origin      string `json:"origin"`
+	Description string `json:"description"`
+}
+
+// NewGitLabReport - export the issues of a report as a GitLab security report of the given type
+func NewGitLabReport(report *model.Report, rules []*model.Rule, reportType GitLabReportType) *GitLabReport {
+	names := ruleNames(rules)
+
+	vulnerabilities := make([]*GitLabVulnerability, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		vulnerabilities = append(vulnerabilities, newGitLabVulnerability(issue, names[issue.RuleID], reportType))
+	}
+
+	tool := GitLabTool{
+		ID:      toolName,
+		Name:    "GitSAST",
+		URL:     toolInformationURI,
+		Version: app.Version,
+		Vendor:  GitLabVendor{Name: "GitSAST"},
+	}
+
+	status := "success"
+	if report.Status != model.StatusSuccess {
+		status = "failure"
+	}
+
+	startTime := report.StartedAt
+	if startTime.IsZero() {
+		startTime = report.CreatedAt
+	}
+
+	endTime := report.FinishedAt
+	if endTime.IsZero() {
+		endTime = startTime
+	}
+
+	return &GitLabReport{
+		Version:         GitLabReportVersion,
+		Vulnerabilities: vulnerabilities,
+		Scan: GitLabScan{
+			Analyzer:  tool,
+			Scanner:   tool,
+			Type:      reportType,
+			StartTime: gitLabTime(startTime),
+			EndTime:   gitLabTime(endTime),
+			Status:    status,
+		},
+	}
+}
+
+func newGitLabVulnerability(issue *model.Issue, name string, reportType GitLabReportType) *GitLabVulnerability {
+	if name == "" {
+		name = issue.Description
+	}
+
+	vulnerability := &GitLabVulnerability{
+		ID:          issue.Fingerprint,
+		Name:        name,
+		Description: issue.Description,
+		Severity:    gitLabSeverity(issue.Severity),
+		Identifiers: []*GitLabIdentifier{
+			{
+				Type:  "gitsast_rule_id",
+				Name:  "GitSAST rule " + issue.RuleID,
+				Value: issue.RuleID,
+			},
+		},
+		Location: GitLabLocation{
+			File:      strings.TrimPrefix(issue.Location.Path, "/"),
+			StartLine: int(issue.Location.Line) + 1,
+		},
+	}
+
+	// issues of reports older than fingerprints have no stable ID
+	if vulnerability.ID == "" {
+		vulnerability.ID = model.NewFingerprint(issue.RuleID, issue.Location.Path, issue.Keyword)
+	}
+
+	if issue.Location.EndLine > issue.Location.Line {
+		vulnerability.Location.EndLine = int(issue.Location.EndLine) + 1
+	}
+
+	// secret detection locations require the commit that introduced the secret
+	if reportType == GitLabSecretDetection {
+		vulnerability.Location.Commit = &GitLabCommit{SHA: gitLabUnknownCommit}
+		if issue.Commit != nil && issue.Commit.SHA != "" {
+			vulnerability.Location.Commit = &GitLabCommit{
+				SHA:    issue.Commit.SHA,
+				Author: issue.Commit.AuthorName,
+			}
+			if !issue.Commit.Date.IsZero() {
+				vulnerability.Location.Commit.Date = gitLabTime(issue.Commit.Date)
+			}
+		}
+	}
+
+	if issue.Triage != nil && issue.Triage.State == model.TriageFalsePositive {
+		vulnerability.Flags = []*GitLabFlag{
+			{
+				Type:        "flagged-as-likely-false-positive",
+				Origin:      toolName,
+				Description: "triaged as false positive",
+			},
+		}
+	}
+
+	return vulnerability
+}
+
+// gitLabSeverity - map a severity to the capitalized severity of GitLab reports
+func gitLabSeverity(severity string) string {
+	score, err := model.ParseScore(severity)
+	if err != nil {
+		return "Unknown"
+	}
+
+	switch score {
+	case model.High:
+		return "High"
+	case model.Medium:
+		return "Medium"
+	}
+
+	return "Low"
+}
+
+func gitLabTime(t time.Time) string {
+	return t.UTC().Format(gitLabTimeLayout)
+}
+
+// gitLabExporter exports a report as a GitLab security report of its report type
+type gitLabExporter struct {
+	format     string
+	reportType GitLabReportType
+}
+
+func (e *gitLabExporter) Format() string      { return e.format }
+func (e *gitLabExporter) ContentType() string { return "application/json" }
+
+func (e *gitLabExporter) Export(w io.Writer, report *model.Report, rules []*model.Rule) error {
+	return writeJSON(w, NewGitLabReport(report, rules, e.reportType))
+}
@@ -0,0 +1,188 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func gitLabTestReport() (*model.Report, []*model.Rule) {
+	started := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	report := &model.Report{
+		ID:         "report-uuid",
+		Status:     model.StatusSuccess,
+		StartedAt:  started,
+		FinishedAt: started.Add(90 * time.Second),
+		Issues: []*model.Issue{
+			{
+				RuleID:      "G002",
+				Location:    model.Location{Path: "/config/priv.key", Line: 4},
+				Description: "A secret starts with the prefix private_key",
+				Severity:    "HIGH",
+				Fingerprint: "active",
+				Commit: &model.Commit{
+					SHA:        "8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e",
+					AuthorName: "Jane Doe",
+					Date:       started.Add(-time.Hour),
+				},
+			},
+			{
+				RuleID:      "G001",
+				Location:    model.Location{Path: "/pub.key", Line: 0, EndLine: 2},
+				Description: "A secret starts with the prefix public_key",
+				Severity:    "LOW",
+				Fingerprint: "false-positive",
+				Triage:      &model.IssueTriage{State: model.TriageFalsePositive},
+			},
+			{
+				RuleID:   "G009",
+				Location: model.Location{Path: "/removed.key"},
+				Keyword:  "removed",
+			},
+		},
+	}
+	rules := []*model.Rule{
+		{ID: 1, Name: "Public key leak", Severity: model.Low},
+		{ID: 2, Name: "Private key leak", Severity: model.High},
+	}
+
+	return report, rules
+}
+
+// the GitLab schemas in testdata cover the fields exported reports set of the release
+// GITLAB_SCHEMA_VERSION of gitlab-org/security-products/security-report-schemas, make
+// gitlab-schemas replaces them with the complete upstream schemas of the release
+var gitLabSchemas = []string{"sast-report-format.json", "secret-detection-report-format.json"}
+
+// gitLabSchemaVersion - the release of the GitLab schemas pinned by the Makefile
+func gitLabSchemaVersion(t *testing.T) string {
+	b, err := os.ReadFile(filepath.Join("..", "..", "Makefile"))
+	assert.NoError(t, err)
+
+	m := regexp.MustCompile(`(?m)^GITLAB_SCHEMA_VERSION\s*:=\s*v(\S+)$`).FindSubmatch(b)
+	if !assert.NotNil(t, m, "GITLAB_SCHEMA_VERSION is not set in the Makefile") {
+		t.FailNow()
+	}
+
+	return string(m[1])
+}
+
+func TestGitLabSchemaVersion(t *testing.T) {
+	version := gitLabSchemaVersion(t)
+	assert.Equal(t, export.GitLabReportVersion, version)
+
+	for _, name := range gitLabSchemas {
+		t.Run(name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", name))
+			assert.NoError(t, err)
+
+			var schema struct {
+				Self struct {
+					Version string `json:"version"`
+				} `json:"self"`
+			}
+			assert.NoError(t, json.Unmarshal(b, &schema))
+			assert.Equal(t, version, schema.Self.Version)
+		})
+	}
+}
+
+func TestNewGitLabReportSchema(t *testing.T) {
+	testCases := []struct {
+		reportType export.GitLabReportType
+		schema     string
+	}{
+		{reportType: export.GitLabSecretDetection, schema: "secret-detection-report-format.json"},
+		{reportType: export.GitLabSAST, schema: "sast-report-format.json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.reportType), func(t *testing.T) {
+			schema, err := jsonschema.Compile(filepath.Join("testdata", tc.schema))
+			assert.NoError(t, err)
+
+			report, rules := gitLabTestReport()
+			b, err := json.Marshal(export.NewGitLabReport(report, rules, tc.reportType))
+			assert.NoError(t, err)
+
+			var doc interface{}
+			assert.NoError(t, json.Unmarshal(b, &doc))
+			assert.NoError(t, schema.Validate(doc))
+		})
+	}
+}
+
+func TestNewGitLabReportSchemaRejectsFindings(t *testing.T) {
+	schema, err := jsonschema.Compile(filepath.Join("testdata", "secret-detection-report-format.json"))
+	assert.NoError(t, err)
+
+	// the findings of the json export are not a GitLab security report
+	report, rules := gitLabTestReport()
+	e, err := export.Get(export.FormatJSON)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, report, rules))
+
+	var doc interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Error(t, schema.Validate(doc))
+}
+
+func TestNewGitLabReport(t *testing.T) {
+	report, rules := gitLabTestReport()
+	gl := export.NewGitLabReport(report, rules, export.GitLabSecretDetection)
+
+	assert.Equal(t, export.GitLabReportVersion, gl.Version)
+	assert.Equal(t, export.GitLabSecretDetection, gl.Scan.Type)
+	assert.Equal(t, "2023-03-01T10:00:00", gl.Scan.StartTime)
+	assert.Equal(t, "2023-03-01T10:01:30", gl.Scan.EndTime)
+	assert.Equal(t, "success", gl.Scan.Status)
+	assert.Len(t, gl.Vulnerabilities, 3)
+
+	vulnerability := gl.Vulnerabilities[0]
+	assert.Equal(t, "active", vulnerability.ID)
+	assert.Equal(t, "Private key leak", vulnerability.Name)
+	assert.Equal(t, "High", vulnerability.Severity)
+	assert.Equal(t, "G002", vulnerability.Identifiers[0].Value)
+	assert.Equal(t, export.GitLabLocation{
+		File:      "config/priv.key",
+		StartLine: 5,
+		Commit: &export.GitLabCommit{
+			SHA:    "8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e",
+			Author: "Jane Doe",
+			Date:   "2023-03-01T09:00:00",
+		},
+	}, vulnerability.Location)
+	assert.Empty(t, vulnerability.Flags)
+
+	assert.Equal(t, 3, gl.Vulnerabilities[1].Location.EndLine)
+	assert.Equal(t, "0000000", gl.Vulnerabilities[1].Location.Commit.SHA)
+	assert.Equal(t, "flagged-as-likely-false-positive", gl.Vulnerabilities[1].Flags[0].Type)
+
+	// issues without fingerprint or known rule still get an ID, a name and a severity
+	assert.NotEmpty(t, gl.Vulnerabilities[2].ID)
+	assert.Equal(t, "Unknown", gl.Vulnerabilities[2].Severity)
+
+	sast := export.NewGitLabReport(report, rules, export.GitLabSAST)
+	assert.Nil(t, sast.Vulnerabilities[0].Location.Commit)
+}
+
+func TestNewGitLabReportFailedScan(t *testing.T) {
+	created := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	gl := export.NewGitLabReport(&model.Report{Status: model.StatusFailed, CreatedAt: created}, nil, export.GitLabSAST)
+
+	assert.Equal(t, "failure", gl.Scan.Status)
+	assert.Equal(t, "2023-03-01T10:00:00", gl.Scan.StartTime)
+	assert.Equal(t, "2023-03-01T10:00:00", gl.Scan.EndTime)
+	assert.Empty(t, gl.Vulnerabilities)
+}
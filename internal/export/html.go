@@ -0,0 +1,81 @@
+package export
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/marktrs/gitsast/internal/model"
+)
+
+//go:embed templates/report.html
+var templates embed.FS
+
+var htmlTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"trimPrefix": strings.TrimPrefix,
+	"line": func(issue *model.Issue) int {
+		line, _ := issueLines(issue)
+		return line
+	},
+	"shortSHA": func(sha string) string {
+		if len(sha) > 7 {
+			return sha[:7]
+		}
+		return sha
+	},
+}).ParseFS(templates, "templates/report.html"))
+
+// severityCount is the number of issues of a severity
+type severityCount struct {
+	Severity string
+	Count    int
+}
+
+// htmlReport is the data of the HTML report template
+type htmlReport struct {
+	Report    *model.Report
+	RuleNames map[string]string
+	Summary   []severityCount
+}
+
+// htmlExporter exports a report as a self-contained HTML page with a severity summary
+type htmlExporter struct{}
+
+func (e *htmlExporter) Format() string      { return FormatHTML }
+func (e *htmlExporter) ContentType() string { return "text/html; charset=utf-8" }
+
+func (e *htmlExporter) Export(w io.Writer, report *model.Report, rules []*model.Rule) error {
+	return htmlTemplate.Execute(w, &htmlReport{
+		Report:    report,
+		RuleNames: ruleNames(rules),
+		Summary:   severityCounts(report.Issues),
+	})
+}
+
+// severityCounts - count issues by severity, from the highest severity to the lowest
+func severityCounts(issues []*model.Issue) []severityCount {
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		counts[issue.Severity]++
+	}
+
+	summary := make([]severityCount, 0, len(counts))
+	for _, score := range []model.Score{model.High, model.Medium, model.Low} {
+		summary = append(summary, severityCount{Severity: score.String(), Count: counts[score.String()]})
+		delete(counts, score.String())
+	}
+
+	others := make([]string, 0, len(counts))
+	for severity := range counts {
+		others = append(others, severity)
+	}
+	sort.Strings(others)
+
+	for _, severity := range others {
+		summary = append(summary, severityCount{Severity: severity, Count: counts[severity]})
+	}
+
+	return summary
+}
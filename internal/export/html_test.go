@@ -0,0 +1,58 @@
+package export_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLExporter(t *testing.T) {
+	e, err := export.Get(export.FormatHTML)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, &model.Report{
+		ID:     "report-uuid",
+		Status: model.StatusSuccess,
+		Issues: []*model.Issue{
+			{
+				RuleID:      "G002",
+				Location:    model.Location{Path: "/priv.key", Line: 2},
+				Description: "Private key leak",
+				Severity:    "HIGH",
+				Snippet:     `private_key="<script>alert(1)</script>"`,
+				Commit:      &model.Commit{SHA: "8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e", AuthorName: "Jane Doe"},
+			},
+			{RuleID: "G001", Location: model.Location{Path: "/pub.key"}, Severity: "LOW"},
+			{RuleID: "G001", Location: model.Location{Path: "/other.key"}, Severity: "LOW"},
+		},
+	}, []*model.Rule{{ID: 2, Name: "Private key leak"}}))
+
+	html := buf.String()
+	assert.Contains(t, html, "<title>GitSAST report report-uuid</title>")
+	assert.Contains(t, html, `<div class="HIGH"><strong>1</strong>HIGH</div>`)
+	assert.Contains(t, html, `<div class="MEDIUM"><strong>0</strong>MEDIUM</div>`)
+	assert.Contains(t, html, `<div class="LOW"><strong>2</strong>LOW</div>`)
+	assert.Contains(t, html, "priv.key:3")
+	assert.Contains(t, html, "8f1d5a3")
+
+	// snippets come from scanned repositories and are escaped
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "&lt;script&gt;")
+
+	// the page is self-contained
+	assert.NotContains(t, html, "<link")
+	assert.NotContains(t, html, "src=")
+}
+
+func TestHTMLExporterNoFindings(t *testing.T) {
+	e, err := export.Get(export.FormatHTML)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, &model.Report{ID: "report-uuid"}, nil))
+	assert.Contains(t, buf.String(), "No findings.")
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/marktrs/gitsast/internal/model"
+)
+
+// junitTimeLayout is the ISO 8601 time format of test suites, without time zone
+const junitTimeLayout = "2006-01-02T15:04:05"
+
+// JUnitTestSuites is a JUnit XML document with a single test suite holding
+// one test case per rule and one failure per issue of the rule
+type JUnitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Suites   []*JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Cases     []*JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string          `xml:"name,attr"`
+	ClassName string          `xml:"classname,attr"`
+	Failures  []*JUnitFailure `xml:"failure"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewJUnit - export the issues of a report as JUnit test suites, rules without
+// issues are passing test cases
+func NewJUnit(report *model.Report, rules []*model.Rule) *JUnitTestSuites {
+	suite := &JUnitTestSuite{Name: toolName}
+	if !report.FinishedAt.IsZero() {
+		suite.Timestamp = report.FinishedAt.UTC().Format(junitTimeLayout)
+	}
+
+	cases := make(map[string]*JUnitTestCase, len(rules))
+	addCase := func(ruleID, name string) *JUnitTestCase {
+		c := &JUnitTestCase{Name: strings.TrimSpace(ruleID + " " + name), ClassName: toolName}
+		cases[ruleID] = c
+		suite.Cases = append(suite.Cases, c)
+		return c
+	}
+
+	for _, rule := range rules {
+		addCase(model.GetFormattedRuleId(rule.ID), rule.Name)
+	}
+
+	for _, issue := range report.Issues {
+		c, ok := cases[issue.RuleID]
+		if !ok {
+			c = addCase(issue.RuleID, issue.Description)
+		}
+
+		line, _ := issueLines(issue)
+		text := issue.Description
+		if issue.Snippet != "" {
+			text += "\n" + issue.Snippet
+		}
+
+		c.Failures = append(c.Failures, &JUnitFailure{
+			Message: fmt.Sprintf("%s:%d", strings.TrimPrefix(issue.Location.Path, "/"), line),
+			Type:    issue.Severity,
+			Text:    text,
+		})
+	}
+
+	suite.Tests = len(suite.Cases)
+	suite.Failures = len(report.Issues)
+
+	return &JUnitTestSuites{
+		Name:     toolName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []*JUnitTestSuite{suite},
+	}
+}
+
+// junitExporter exports a report as a JUnit XML document for CI test report viewers
+type junitExporter struct{}
+
+func (e *junitExporter) Format() string      { return FormatJUnit }
+func (e *junitExporter) ContentType() string { return "application/xml" }
+
+func (e *junitExporter) Export(w io.Writer, report *model.Report, rules []*model.Rule) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(NewJUnit(report, rules)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
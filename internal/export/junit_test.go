@@ -0,0 +1,51 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitExporter(t *testing.T) {
+	e, err := export.Get(export.FormatJUnit)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, e.Export(&buf, &model.Report{
+		Issues: []*model.Issue{
+			{RuleID: "G002", Location: model.Location{Path: "/priv.key", Line: 1}, Severity: "HIGH", Snippet: "private_key=abc"},
+			{RuleID: "G002", Location: model.Location{Path: "/other.key"}, Severity: "HIGH"},
+			{RuleID: "G009", Location: model.Location{Path: "/removed.key"}, Description: "Removed rule", Severity: "LOW"},
+		},
+	}, []*model.Rule{
+		{ID: 1, Name: "Public key leak"},
+		{ID: 2, Name: "Private key leak"},
+	}))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	var suites export.JUnitTestSuites
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 3, suites.Failures)
+
+	cases := suites.Suites[0].Cases
+	assert.Len(t, cases, 3)
+
+	// rules without issues pass
+	assert.Equal(t, "G001 Public key leak", cases[0].Name)
+	assert.Empty(t, cases[0].Failures)
+
+	assert.Equal(t, "G002 Private key leak", cases[1].Name)
+	assert.Len(t, cases[1].Failures, 2)
+	assert.Equal(t, "priv.key:2", cases[1].Failures[0].Message)
+	assert.Equal(t, "HIGH", cases[1].Failures[0].Type)
+	assert.Contains(t, cases[1].Failures[0].Text, "private_key=abc")
+
+	assert.Equal(t, "G009 Removed rule", cases[2].Name)
+	assert.Len(t, cases[2].Failures, 1)
+}
@@ -0,0 +1,248 @@
+package export
+
+import (
+	"io"
+	"strings"
+
+	"github.com/marktrs/gitsast/internal/model"
+)
+
+const (
+	// SARIFVersion is the version of the SARIF specification of exported logs
+	SARIFVersion = "2.1.0"
+	// SARIFSchema is the JSON schema of SARIF 2.1.0 logs
+	SARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// SARIFContentType is the media type of SARIF logs
+	SARIFContentType = "application/sarif+json"
+
+	// sarifFingerprintKey versions the fingerprint algorithm in partialFingerprints,
+	// see model.NewFingerprint
+	sarifFingerprintKey = "gitsast/v1"
+	// sarifSourceRoot is the base of artifact URIs, relative to the repository root
+	sarifSourceRoot = "%SRCROOT%"
+
+	toolName           = "gitsast"
+	toolInformationURI = "https://github.com/marktrs/gitsast"
+)
+
+// SARIF is a SARIF 2.1.0 log holding the results of a single report
+type SARIF struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool              SARIFTool              `json:"tool"`
+	AutomationDetails *SARIFAutomationDetail `json:"automationDetails,omitempty"`
+	Results           []*SARIFResult         `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*SARIFRule `json:"rules"`
+}
+
+// SARIFAutomationDetail identifies the report a run was exported from
+type SARIFAutomationDetail struct {
+	ID string `json:"id"`
+}
+
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	FullDescription      *SARIFMessage      `json:"fullDescription,omitempty"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]string  `json:"properties,omitempty"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID              string              `json:"ruleId"`
+	RuleIndex           int                 `json:"ruleIndex"`
+	Level               string              `json:"level"`
+	Message             SARIFMessage        `json:"message"`
+	Locations           []*SARIFLocation    `json:"locations"`
+	PartialFingerprints map[string]string   `json:"partialFingerprints,omitempty"`
+	Suppressions        []*SARIFSuppression `json:"suppressions,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           SARIFRegion           `json:"region"`
+}
+
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+// SARIFRegion is the lines of a result, SARIF lines start at 1
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// SARIFSuppression tells viewers that a result was reviewed and should be hidden
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// NewSARIF - export the issues of a report as a SARIF log, rules no longer existing
+// are described from the issues referencing them
+func NewSARIF(report *model.Report, rules []*model.Rule) *SARIF {
+	driver := SARIFDriver{
+		Name:           toolName,
+		InformationURI: toolInformationURI,
+		Rules:          make([]*SARIFRule, 0, len(rules)),
+	}
+
+	ruleIndex := make(map[string]int, len(rules))
+	for _, rule := range rules {
+		id := model.GetFormattedRuleId(rule.ID)
+		ruleIndex[id] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, newSARIFRule(id, rule.Name, rule.Description, rule.Severity.String()))
+	}
+
+	results := make([]*SARIFResult, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		index, ok := ruleIndex[issue.RuleID]
+		if !ok {
+			index = len(driver.Rules)
+			ruleIndex[issue.RuleID] = index
+			driver.Rules = append(driver.Rules, newSARIFRule(issue.RuleID, "", issue.Description, issue.Severity))
+		}
+
+		results = append(results, newSARIFResult(issue, index))
+	}
+
+	return &SARIF{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []*SARIFRun{
+			{
+				Tool:              SARIFTool{Driver: driver},
+				AutomationDetails: &SARIFAutomationDetail{ID: report.ID},
+				Results:           results,
+			},
+		},
+	}
+}
+
+func newSARIFRule(id, name, description, severity string) *SARIFRule {
+	rule := &SARIFRule{
+		ID:                   id,
+		Name:                 name,
+		ShortDescription:     SARIFMessage{Text: description},
+		DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(severity)},
+		Properties:           map[string]string{"severity": severity},
+	}
+
+	if name != "" {
+		rule.ShortDescription = SARIFMessage{Text: name}
+		rule.FullDescription = &SARIFMessage{Text: description}
+	}
+
+	return rule
+}
+
+func newSARIFResult(issue *model.Issue, ruleIndex int) *SARIFResult {
+	region := SARIFRegion{StartLine: int(issue.Location.Line) + 1}
+	if issue.Location.EndLine > issue.Location.Line {
+		region.EndLine = int(issue.Location.EndLine) + 1
+	}
+
+	result := &SARIFResult{
+		RuleID:    issue.RuleID,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(issue.Severity),
+		Message:   SARIFMessage{Text: issue.Description},
+		Locations: []*SARIFLocation{
+			{
+				PhysicalLocation: SARIFPhysicalLocation{
+					ArtifactLocation: SARIFArtifactLocation{
+						URI:       strings.TrimPrefix(issue.Location.Path, "/"),
+						URIBaseID: sarifSourceRoot,
+					},
+					Region: region,
+				},
+			},
+		},
+	}
+
+	if issue.Fingerprint != "" {
+		result.PartialFingerprints = map[string]string{sarifFingerprintKey: issue.Fingerprint}
+	}
+
+	if suppression := sarifSuppression(issue); suppression != nil {
+		result.Suppressions = []*SARIFSuppression{suppression}
+	}
+
+	return result
+}
+
+// sarifSuppression - map baselined issues and triage decisions to a suppression
+func sarifSuppression(issue *model.Issue) *SARIFSuppression {
+	if issue.Status == model.IssueStatusBaselined {
+		return &SARIFSuppression{Kind: "external", Status: "accepted", Justification: "accepted by baseline"}
+	}
+
+	if issue.Triage == nil {
+		return nil
+	}
+
+	switch issue.Triage.State {
+	case model.TriageFalsePositive:
+		return &SARIFSuppression{Kind: "external", Status: "accepted", Justification: "triaged as false positive"}
+	case model.TriageAcceptedRisk:
+		return &SARIFSuppression{Kind: "external", Status: "accepted", Justification: "triaged as accepted risk"}
+	}
+
+	return nil
+}
+
+// sarifLevel - map a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	score, err := model.ParseScore(severity)
+	if err != nil {
+		return "warning"
+	}
+
+	switch score {
+	case model.High:
+		return "error"
+	case model.Medium:
+		return "warning"
+	}
+
+	return "note"
+}
+
+// sarifExporter exports a report as a SARIF log
+type sarifExporter struct{}
+
+func (e *sarifExporter) Format() string      { return FormatSARIF }
+func (e *sarifExporter) ContentType() string { return SARIFContentType }
+
+func (e *sarifExporter) Export(w io.Writer, report *model.Report, rules []*model.Rule) error {
+	return writeJSON(w, NewSARIF(report, rules))
+}
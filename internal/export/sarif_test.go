@@ -0,0 +1,104 @@
+package export_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSARIF(t *testing.T) {
+	report := &model.Report{
+		ID: "report-uuid",
+		Issues: []*model.Issue{
+			{
+				RuleID:      "G002",
+				Location:    model.Location{Path: "/config/priv.key", Line: 4, EndLine: 6},
+				Description: "A secret starts with the prefix private_key",
+				Severity:    "HIGH",
+				Fingerprint: "active",
+				Status:      model.IssueStatusActive,
+				Triage:      &model.IssueTriage{State: model.TriageOpen},
+			},
+			{
+				RuleID:      "G001",
+				Location:    model.Location{Path: "/pub.key"},
+				Severity:    "LOW",
+				Fingerprint: "baselined",
+				Status:      model.IssueStatusBaselined,
+			},
+			{
+				RuleID:      "G001",
+				Location:    model.Location{Path: "/other.key"},
+				Severity:    "LOW",
+				Fingerprint: "false-positive",
+				Triage:      &model.IssueTriage{State: model.TriageFalsePositive},
+			},
+			{
+				RuleID:      "G009",
+				Location:    model.Location{Path: "/removed.key"},
+				Description: "Removed rule",
+				Severity:    "MEDIUM",
+			},
+		},
+	}
+	rules := []*model.Rule{
+		{ID: 1, Name: "Public key leak", Description: "A secret starts with the prefix public_key", Severity: model.Low},
+		{ID: 2, Name: "Private key leak", Description: "A secret starts with the prefix private_key", Severity: model.High},
+	}
+
+	log := export.NewSARIF(report, rules)
+
+	assert.Equal(t, export.SARIFVersion, log.Version)
+	assert.Equal(t, export.SARIFSchema, log.Schema)
+	assert.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "report-uuid", run.AutomationDetails.ID)
+
+	// rules no longer existing are described from their issues
+	assert.Len(t, run.Tool.Driver.Rules, 3)
+	assert.Equal(t, "G001", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "Public key leak", run.Tool.Driver.Rules[0].ShortDescription.Text)
+	assert.Equal(t, "note", run.Tool.Driver.Rules[0].DefaultConfiguration.Level)
+	assert.Equal(t, "error", run.Tool.Driver.Rules[1].DefaultConfiguration.Level)
+	assert.Equal(t, "G009", run.Tool.Driver.Rules[2].ID)
+	assert.Equal(t, "Removed rule", run.Tool.Driver.Rules[2].ShortDescription.Text)
+
+	assert.Len(t, run.Results, 4)
+
+	result := run.Results[0]
+	assert.Equal(t, "G002", result.RuleID)
+	assert.Equal(t, 1, result.RuleIndex)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, "config/priv.key", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, export.SARIFRegion{StartLine: 5, EndLine: 7}, result.Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, map[string]string{"gitsast/v1": "active"}, result.PartialFingerprints)
+	assert.Empty(t, result.Suppressions)
+
+	assert.Equal(t, 0, run.Results[1].RuleIndex)
+	assert.Equal(t, export.SARIFRegion{StartLine: 1}, run.Results[1].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, []*export.SARIFSuppression{
+		{Kind: "external", Status: "accepted", Justification: "accepted by baseline"},
+	}, run.Results[1].Suppressions)
+	assert.Equal(t, "triaged as false positive", run.Results[2].Suppressions[0].Justification)
+
+	assert.Equal(t, 2, run.Results[3].RuleIndex)
+	assert.Equal(t, "warning", run.Results[3].Level)
+}
+
+func TestNewSARIFEmptyReport(t *testing.T) {
+	b, err := json.Marshal(export.NewSARIF(&model.Report{ID: "report-uuid"}, nil))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": [{
+			"tool": {"driver": {"name": "gitsast", "informationUri": "https://github.com/marktrs/gitsast", "rules": []}},
+			"automationDetails": {"id": "report-uuid"},
+			"results": []
+		}]
+	}`, string(b))
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// BaselineFileName - name of the baseline file committed at the root of a repository
+const BaselineFileName = ".gitsast-baseline.json"
+
+// Baseline lists fingerprints of known findings which are accepted as pre-existing
+type Baseline struct {
+	// ReportID is the report the baseline was generated from, if any
+	ReportID     string    `json:"report_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Fingerprints []string  `json:"fingerprints" validate:"required,dive,len=64,hexadecimal"`
+}
+
+// NewBaseline - create a baseline accepting every finding of a report
+func NewBaseline(report *Report) *Baseline {
+	seen := make(map[string]bool, len(report.Issues))
+	fingerprints := make([]string, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		if issue.Fingerprint == "" || seen[issue.Fingerprint] {
+			continue
+		}
+		seen[issue.Fingerprint] = true
+		fingerprints = append(fingerprints, issue.Fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	return &Baseline{
+		ReportID:     report.ID,
+		CreatedAt:    time.Now(),
+		Fingerprints: fingerprints,
+	}
+}
+
+// ParseBaseline - parse a baseline from its JSON representation
+func ParseBaseline(b []byte) (*Baseline, error) {
+	var baseline Baseline
+	if err := json.Unmarshal(b, &baseline); err != nil {
+		return nil, err
+	}
+
+	return &baseline, nil
+}
+
+// ApplyBaselines - mark issues matching any of the baselines as baselined, others as active
+func ApplyBaselines(issues []*Issue, baselines ...*Baseline) {
+	known := make(map[string]bool)
+	for _, baseline := range baselines {
+		if baseline == nil {
+			continue
+		}
+		for _, fingerprint := range baseline.Fingerprints {
+			known[fingerprint] = true
+		}
+	}
+
+	for _, issue := range issues {
+		issue.Status = IssueStatusActive
+		if issue.Fingerprint != "" && known[issue.Fingerprint] {
+			issue.Status = IssueStatusBaselined
+		}
+	}
+}
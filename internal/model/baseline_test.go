@@ -0,0 +1,51 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBaseline(t *testing.T) {
+	report := &model.Report{
+		ID: "report-uuid",
+		Issues: []*model.Issue{
+			{Fingerprint: "b"},
+			{Fingerprint: "a"},
+			{Fingerprint: "b"},
+			{},
+		},
+	}
+
+	baseline := model.NewBaseline(report)
+
+	assert.Equal(t, "report-uuid", baseline.ReportID)
+	assert.Equal(t, []string{"a", "b"}, baseline.Fingerprints)
+	assert.False(t, baseline.CreatedAt.IsZero())
+}
+
+func TestApplyBaselines(t *testing.T) {
+	uploaded, err := model.ParseBaseline([]byte(`{"fingerprints": ["uploaded"]}`))
+	assert.NoError(t, err)
+
+	issues := []*model.Issue{
+		{Fingerprint: "uploaded"},
+		{Fingerprint: "committed"},
+		{Fingerprint: "new"},
+		{},
+	}
+
+	model.ApplyBaselines(issues, uploaded, nil, &model.Baseline{Fingerprints: []string{"committed"}})
+
+	assert.Equal(t, model.IssueStatusBaselined, issues[0].Status)
+	assert.Equal(t, model.IssueStatusBaselined, issues[1].Status)
+	assert.Equal(t, model.IssueStatusActive, issues[2].Status)
+	assert.Equal(t, model.IssueStatusActive, issues[3].Status)
+}
+
+func TestParseBaselineError(t *testing.T) {
+	_, err := model.ParseBaseline([]byte(strings.Repeat("{", 2)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package model
+
+import "fmt"
+
+// ReportDiff holds issues of a report classified against a base report
+type ReportDiff struct {
+	// New issues are found in the report but not in the base report
+	New []*Issue
+	// Fixed issues are found in the base report but not in the report anymore
+	Fixed []*Issue
+	// Unchanged issues are found in both reports
+	Unchanged []*Issue
+}
+
+// DiffIssues - classify issues of a report as new, fixed or unchanged compared to the base issues
+func DiffIssues(base, issues []*Issue) *ReportDiff {
+	diff := &ReportDiff{
+		New:       make([]*Issue, 0),
+		Fixed:     make([]*Issue, 0),
+		Unchanged: make([]*Issue, 0),
+	}
+
+	baseKeys := make(map[string]bool, len(base))
+	for _, issue := range base {
+		baseKeys[issue.diffKey()] = true
+	}
+
+	keys := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		key := issue.diffKey()
+		keys[key] = true
+
+		if baseKeys[key] {
+			diff.Unchanged = append(diff.Unchanged, issue)
+		} else {
+			diff.New = append(diff.New, issue)
+		}
+	}
+
+	for _, issue := range base {
+		if !keys[issue.diffKey()] {
+			diff.Fixed = append(diff.Fixed, issue)
+		}
+	}
+
+	return diff
+}
+
+// diffKey - key used to match the same issue across reports,
+// issues stored before fingerprints were introduced fall back to their location
+func (i *Issue) diffKey() string {
+	if i.Fingerprint != "" {
+		return i.Fingerprint
+	}
+
+	return fmt.Sprintf("%s:%s:%d", i.RuleID, i.Location.Path, i.Location.Line)
+}
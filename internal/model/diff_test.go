@@ -0,0 +1,30 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffIssues(t *testing.T) {
+	kept := &model.Issue{RuleID: "G001", Fingerprint: "kept"}
+	fixed := &model.Issue{RuleID: "G002", Fingerprint: "fixed"}
+	added := &model.Issue{RuleID: "G002", Fingerprint: "added"}
+	legacy := &model.Issue{RuleID: "G001", Location: model.Location{Path: "/a.txt", Line: 3}}
+
+	diff := model.DiffIssues(
+		[]*model.Issue{kept, fixed, legacy},
+		[]*model.Issue{{RuleID: "G001", Fingerprint: "kept"}, added, {RuleID: "G001", Location: model.Location{Path: "/a.txt", Line: 3}}},
+	)
+
+	assert.Equal(t, []*model.Issue{added}, diff.New)
+	assert.Equal(t, []*model.Issue{fixed}, diff.Fixed)
+	assert.Len(t, diff.Unchanged, 2)
+	assert.Equal(t, "kept", diff.Unchanged[0].Fingerprint)
+
+	diff = model.DiffIssues(nil, []*model.Issue{added})
+	assert.Equal(t, []*model.Issue{added}, diff.New)
+	assert.Empty(t, diff.Fixed)
+	assert.Empty(t, diff.Unchanged)
+}
@@ -1,8 +1,22 @@
 package model
 
-import "errors"
+import (
+	"errors"
+)
 
 var (
 	ErrReportInProgress = errors.New(
 		`the report for this repository already initialized, only completed/failed report can retry`)
+	ErrReportNotCompleted = errors.New(
+		`the report is not completed yet, only successful reports can be compared`)
+	ErrReportRepositoryMismatch = errors.New(
+		`the report does not belong to this repository`)
+	ErrReportNotCancellable = errors.New(
+		`only enqueued or in-progress reports can be cancelled`)
+	ErrReportStatusChanged = errors.New(
+		`the report status was changed meanwhile`)
+	ErrInvalidQueryParam = errors.New(
+		`invalid query param value`)
+	ErrScanTimeout = errors.New(
+		`timeout`)
 )
@@ -2,12 +2,20 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bunrouter"
 )
 
+// InvalidQueryParam - return the error of an invalid query param value wrapping
+// ErrInvalidQueryParam, the cause is optional
+func InvalidQueryParam(name string, cause error) error {
+	return errors.Join(fmt.Errorf("%w: %s", ErrInvalidQueryParam, name), cause)
+}
+
 type RepositoryFilter struct {
 	ID        string
 	Name      string
@@ -37,7 +45,7 @@ func DecodeRepositoryFilter(req bunrouter.Request) (*RepositoryFilter, error) {
 	if query.Has("limit") {
 		limit, err = strconv.Atoi(query.Get("limit"))
 		if err != nil {
-			return nil, errors.Join(errors.New("invalid query param value: limit"), err)
+			return nil, InvalidQueryParam("limit", err)
 		}
 		f.Limit = limit
 	}
@@ -45,7 +53,7 @@ func DecodeRepositoryFilter(req bunrouter.Request) (*RepositoryFilter, error) {
 	if query.Has("offset") {
 		offset, err = strconv.Atoi(query.Get("offset"))
 		if err != nil {
-			return nil, errors.Join(errors.New("invalid query param value: offset"), err)
+			return nil, InvalidQueryParam("offset", err)
 		}
 		f.Offset = offset
 	}
@@ -57,3 +65,277 @@ func (f *RepositoryFilter) query(q *bun.SelectQuery) *bun.SelectQuery {
 	// TODO: Add relation query
 	return q
 }
+
+type ReportListFilter struct {
+	Status ReportStatus
+	// Order sorts reports by creation time, asc or desc
+	Order string
+
+	Limit  int
+	Offset int
+}
+
+// DecodeReportListFilter - decode report list filter query from request
+func DecodeReportListFilter(req bunrouter.Request) (*ReportListFilter, error) {
+	var err error
+	// default
+	limit := 100
+	offset := 0
+
+	query := req.URL.Query()
+
+	f := &ReportListFilter{
+		Status: ReportStatus(query.Get("status")),
+		Order:  "desc",
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if query.Has("order") {
+		f.Order = query.Get("order")
+		if f.Order != "asc" && f.Order != "desc" {
+			return nil, InvalidQueryParam("order", nil)
+		}
+	}
+
+	if query.Has("limit") {
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			return nil, InvalidQueryParam("limit", err)
+		}
+		f.Limit = limit
+	}
+
+	if query.Has("offset") {
+		offset, err = strconv.Atoi(query.Get("offset"))
+		if err != nil {
+			return nil, InvalidQueryParam("offset", err)
+		}
+		f.Offset = offset
+	}
+
+	return f, nil
+}
+
+func (f *ReportListFilter) query(q *bun.SelectQuery) *bun.SelectQuery {
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+
+	if f.Order == "asc" {
+		return q.OrderExpr("created_at ASC")
+	}
+
+	return q.OrderExpr("created_at DESC")
+}
+
+// maxIssueListLimit caps the page size of issue lists
+const maxIssueListLimit = 1000
+
+// IssueFilter filters the issues of a report, pages are selected by
+// cursor instead of offset so large reports can be walked efficiently
+type IssueFilter struct {
+	// Severity filters issues by severity, e.g. HIGH
+	Severity string
+	// RuleID filters issues by rule, e.g. G001
+	RuleID string
+	// PathPrefix filters issues by the path of the file they are found in
+	PathPrefix string
+	// Status filters issues by status, e.g. to hide baselined issues
+	Status IssueStatus
+	// Author filters issues by the name or email of the commit author, see MatchIssue
+	Author string
+	// Triage filters issues by triage state, see MatchIssue
+	Triage TriageState
+
+	Limit int
+	// Cursor is the ID of the last issue of the previous page
+	Cursor uint64
+}
+
+// DecodeIssueFilter - decode issue filter query from request
+func DecodeIssueFilter(req bunrouter.Request) (*IssueFilter, error) {
+	var err error
+
+	query := req.URL.Query()
+
+	f := &IssueFilter{
+		RuleID:     query.Get("rule"),
+		PathPrefix: query.Get("path_prefix"),
+		Status:     IssueStatus(query.Get("status")),
+		Author:     query.Get("author"),
+		Triage:     TriageState(query.Get("triage")),
+		Limit:      100,
+	}
+
+	if query.Has("severity") {
+		score, err := ParseScore(query.Get("severity"))
+		if err != nil {
+			return nil, InvalidQueryParam("severity", err)
+		}
+		f.Severity = score.String()
+	}
+
+	switch f.Status {
+	case "", IssueStatusActive, IssueStatusBaselined:
+	default:
+		return nil, InvalidQueryParam("status", nil)
+	}
+
+	if f.Triage != "" && !f.Triage.IsValid() {
+		return nil, InvalidQueryParam("triage", nil)
+	}
+
+	// issue paths are stored relative to the repository root with a leading slash
+	if f.PathPrefix != "" && !strings.HasPrefix(f.PathPrefix, "/") {
+		f.PathPrefix = "/" + f.PathPrefix
+	}
+
+	if query.Has("limit") {
+		f.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			return nil, InvalidQueryParam("limit", err)
+		}
+		if f.Limit < 1 || f.Limit > maxIssueListLimit {
+			return nil, InvalidQueryParam("limit", nil)
+		}
+	}
+
+	if query.Has("cursor") {
+		f.Cursor, err = strconv.ParseUint(query.Get("cursor"), 10, 64)
+		if err != nil {
+			return nil, InvalidQueryParam("cursor", err)
+		}
+	}
+
+	return f, nil
+}
+
+func (f *IssueFilter) query(q *bun.SelectQuery) *bun.SelectQuery {
+	if f.Severity != "" {
+		q = q.Where("severity = ?", f.Severity)
+	}
+
+	if f.RuleID != "" {
+		q = q.Where("rule_id = ?", f.RuleID)
+	}
+
+	if f.PathPrefix != "" {
+		q = q.Where(`location_path LIKE ? ESCAPE '\'`, escapeLike(f.PathPrefix)+"%")
+	}
+
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+
+	if f.Cursor > 0 {
+		q = q.Where("id > ?", f.Cursor)
+	}
+
+	return q.OrderExpr("id ASC")
+}
+
+// MatchIssue - check if the issue matches the author and triage filters, which are not
+// applied by the query. The triage state of the issue must be carried forward first.
+func (f *IssueFilter) MatchIssue(issue *Issue) bool {
+	if f.Author != "" && !issue.Commit.IsAuthoredBy(f.Author) {
+		return false
+	}
+
+	if f.Triage != "" && (issue.Triage == nil || issue.Triage.State != f.Triage) {
+		return false
+	}
+
+	return true
+}
+
+// escapeLike - escape the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// ReportLatest defines which report of a repository is the latest one
+type ReportLatest string
+
+const (
+	// LatestCreated is the most recently created report, whatever its status
+	LatestCreated ReportLatest = "created"
+	// LatestSuccessful is the most recently created successful report
+	LatestSuccessful ReportLatest = "successful"
+)
+
+// ReportFilter selects the latest report of a repository and filters the issues of
+// exported reports, reports are returned without issues, see IssueFilter
+type ReportFilter struct {
+	// Latest selects the latest report of a repository, defaults to LatestCreated
+	Latest ReportLatest
+	// Author filters issues by the name or email of the commit author
+	Author string
+	// Status filters issues by status, e.g. to hide baselined issues
+	Status IssueStatus
+	// Triage filters issues by triage state
+	Triage TriageState
+}
+
+// DecodeReportFilter - decode report filter query from request
+func DecodeReportFilter(req bunrouter.Request) (*ReportFilter, error) {
+	query := req.URL.Query()
+
+	f := &ReportFilter{
+		Latest: LatestCreated,
+		Author: query.Get("author"),
+		Status: IssueStatus(query.Get("status")),
+		Triage: TriageState(query.Get("triage")),
+	}
+
+	if query.Has("latest") {
+		f.Latest = ReportLatest(query.Get("latest"))
+		if f.Latest != LatestCreated && f.Latest != LatestSuccessful {
+			return nil, InvalidQueryParam("latest", nil)
+		}
+	}
+
+	switch f.Status {
+	case "", IssueStatusActive, IssueStatusBaselined:
+	default:
+		return nil, InvalidQueryParam("status", nil)
+	}
+
+	if f.Triage != "" && !f.Triage.IsValid() {
+		return nil, InvalidQueryParam("triage", nil)
+	}
+
+	return f, nil
+}
+
+// FilterIssues - return the issues matching the report filter
+func (f *ReportFilter) FilterIssues(issues []*Issue) []*Issue {
+	matched := make([]*Issue, 0, len(issues))
+	for _, issue := range issues {
+		if f.MatchIssue(issue) {
+			matched = append(matched, issue)
+		}
+	}
+	return matched
+}
+
+// MatchIssue - check if the issue matches the report filter
+func (f *ReportFilter) MatchIssue(issue *Issue) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.Author != "" && !issue.Commit.IsAuthoredBy(f.Author) {
+		return false
+	}
+
+	if f.Status != "" && issue.Status != f.Status {
+		return false
+	}
+
+	if f.Triage != "" && (issue.Triage == nil || issue.Triage.State != f.Triage) {
+		return false
+	}
+
+	return true
+}
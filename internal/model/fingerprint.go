@@ -0,0 +1,51 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// NewFingerprint - generate a stable fingerprint of an issue from its rule ID, path and matched content.
+// The line number is left out so that the fingerprint survives lines being added or removed around it.
+func NewFingerprint(ruleID, path, content string) string {
+	// normalize whitespaces so that re-indenting a line keeps the same fingerprint
+	contentHash := sha256.Sum256([]byte(strings.Join(strings.Fields(content), " ")))
+
+	h := sha256.New()
+	h.Write([]byte(ruleID))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimPrefix(path, "/")))
+	h.Write([]byte{0})
+	h.Write(contentHash[:])
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DedupeIssues - merge issues sharing the same fingerprint into the first one found,
+// counting how many times the same finding occurred
+func DedupeIssues(issues []*Issue) []*Issue {
+	deduped := make([]*Issue, 0, len(issues))
+	seen := make(map[string]*Issue, len(issues))
+
+	for _, issue := range issues {
+		if issue.Fingerprint == "" {
+			deduped = append(deduped, issue)
+			continue
+		}
+
+		if first, ok := seen[issue.Fingerprint]; ok {
+			first.Occurrences++
+			continue
+		}
+
+		if issue.Occurrences == 0 {
+			issue.Occurrences = 1
+		}
+
+		seen[issue.Fingerprint] = issue
+		deduped = append(deduped, issue)
+	}
+
+	return deduped
+}
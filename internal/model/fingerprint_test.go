@@ -0,0 +1,35 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFingerprint(t *testing.T) {
+	fp := model.NewFingerprint("G002", "/config.yaml", "private_key: abc")
+
+	assert.Len(t, fp, 64)
+	assert.Equal(t, fp, model.NewFingerprint("G002", "config.yaml", "  private_key:\tabc\n"))
+	assert.NotEqual(t, fp, model.NewFingerprint("G001", "config.yaml", "private_key: abc"))
+	assert.NotEqual(t, fp, model.NewFingerprint("G002", "other.yaml", "private_key: abc"))
+	assert.NotEqual(t, fp, model.NewFingerprint("G002", "config.yaml", "private_key: def"))
+}
+
+func TestDedupeIssues(t *testing.T) {
+	issues := []*model.Issue{
+		{RuleID: "G002", Location: model.Location{Line: 1}, Fingerprint: "a"},
+		{RuleID: "G001", Location: model.Location{Line: 2}, Fingerprint: "b"},
+		{RuleID: "G002", Location: model.Location{Line: 7}, Fingerprint: "a"},
+		{RuleID: "G002", Location: model.Location{Line: 9}},
+	}
+
+	deduped := model.DedupeIssues(issues)
+
+	assert.Len(t, deduped, 3)
+	assert.Equal(t, uint64(1), deduped[0].Location.Line)
+	assert.Equal(t, 2, deduped[0].Occurrences)
+	assert.Equal(t, 1, deduped[1].Occurrences)
+	assert.Equal(t, 0, deduped[2].Occurrences)
+}
@@ -1,9 +1,18 @@
 package model
 
+import (
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
 type Finding struct {
-	Type     string `json:"type"`
-	RuleID   string `json:"ruleId"`
-	Location struct {
+	Type        string      `json:"type"`
+	RuleID      string      `json:"ruleId"`
+	Fingerprint string      `json:"fingerprint"`
+	Status      IssueStatus `json:"status,omitempty"`
+	Location    struct {
 		Path     string `json:"path"`
 		Position struct {
 			Begin struct {
@@ -15,17 +24,104 @@ type Finding struct {
 		Description string `json:"description"`
 		Severity    string `json:"severity"`
 	} `json:"metadata"`
+	Submodule *Submodule   `json:"submodule,omitempty"`
+	Commit    *Commit      `json:"commit,omitempty"`
+	Triage    *IssueTriage `json:"triage,omitempty"`
+}
+
+// NewFinding - convert an issue into a finding of a report response
+func NewFinding(issue *Issue) *Finding {
+	var finding Finding
+	finding.Type = "sast"
+	finding.RuleID = issue.RuleID
+	finding.Fingerprint = issue.Fingerprint
+	finding.Status = issue.Status
+	finding.Location.Path = issue.Location.Path
+	finding.Location.Position.Begin.Line = int(issue.Location.Line)
+	finding.Metadata.Description = issue.Description
+	finding.Metadata.Severity = issue.Severity
+	finding.Submodule = issue.Submodule
+	finding.Commit = issue.Commit
+	finding.Triage = issue.Triage
+	return &finding
+}
+
+// NewFindings - convert issues into findings of a report response
+func NewFindings(issues []*Issue) []*Finding {
+	findings := make([]*Finding, 0, len(issues))
+	for _, issue := range issues {
+		findings = append(findings, NewFinding(issue))
+	}
+	return findings
 }
 
 type Issue struct {
-	RuleID      string   `json:"ruleId"`
-	Location    Location `json:"location"`
+	bun.BaseModel `bun:"table:issues,alias:issue"`
+
+	// ID orders the issues of a report, it is used as pagination cursor
+	ID       uint64 `json:"-" bun:",pk,autoincrement"`
+	ReportID string `json:"-" bun:",type:uuid,notnull"`
+
+	RuleID      string   `json:"ruleId" bun:",notnull"`
+	Location    Location `json:"location" bun:"embed:location_"`
 	Description string   `json:"description"`
-	Severity    string   `json:"severity"`
+	Severity    string   `json:"severity" bun:",notnull"`
 	Keyword     string   `json:"keyword"`
+	// Fingerprint identifies the same finding across reports, see NewFingerprint
+	Fingerprint string `json:"fingerprint"`
+	// Snippet is the matched source code, see NewSnippet
+	Snippet string `json:"snippet,omitempty"`
+	// Status tells whether the issue is active or accepted by a baseline
+	Status IssueStatus `json:"status,omitempty"`
+	// Occurrences is the number of matches merged into this issue by DedupeIssues
+	Occurrences int `json:"occurrences,omitempty"`
+	// Submodule is set when the issue was found inside a git submodule
+	Submodule *Submodule `json:"submodule,omitempty"`
+	// Commit is the commit that introduced the matched line according to git blame
+	Commit *Commit `json:"commit,omitempty"`
+	// Triage is the triage state of the finding carried forward from previous reports
+	Triage *IssueTriage `json:"triage,omitempty" bun:"-"`
+}
+
+// maxSnippetLength caps the length of issue snippets, in runes
+const maxSnippetLength = 200
+
+// NewSnippet - trim the lines matched by an issue into a short code snippet
+func NewSnippet(content string) string {
+	snippet := []rune(strings.TrimSpace(content))
+	if len(snippet) > maxSnippetLength {
+		return string(snippet[:maxSnippetLength]) + "…"
+	}
+	return string(snippet)
 }
 
+type IssueStatus string
+
+const (
+	IssueStatusActive    IssueStatus = "active"
+	IssueStatusBaselined IssueStatus = "baselined"
+)
+
 type Location struct {
 	Path string `json:"path"`
 	Line uint64 `json:"line"`
+	// EndLine is the last line of a match spanning multiple lines
+	EndLine uint64 `json:"endLine,omitempty"`
+}
+
+// Commit holds the git blame attribution of an issue
+type Commit struct {
+	SHA         string    `json:"sha"`
+	AuthorName  string    `json:"authorName"`
+	AuthorEmail string    `json:"authorEmail"`
+	Date        time.Time `json:"date"`
+}
+
+// IsAuthoredBy - check if the commit author name or email matches the given author, case insensitive
+func (c *Commit) IsAuthoredBy(author string) bool {
+	if c == nil {
+		return false
+	}
+
+	return strings.EqualFold(c.AuthorEmail, author) || strings.EqualFold(c.AuthorName, author)
 }
@@ -0,0 +1,18 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSnippet(t *testing.T) {
+	assert.Equal(t, "private_key=abc", model.NewSnippet("\t  private_key=abc\n"))
+
+	snippet := model.NewSnippet(strings.Repeat("é", 300))
+	assert.Equal(t, 201, utf8.RuneCountInString(snippet))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/marktrs/gitsast/app"
+)
+
+type PolicyStatus string
+
+const (
+	PolicyPass PolicyStatus = "pass"
+	PolicyFail PolicyStatus = "fail"
+)
+
+// PolicySource tells which policy was evaluated
+type PolicySource string
+
+const (
+	PolicySourceRepository PolicySource = "repository"
+	PolicySourceGlobal     PolicySource = "global"
+)
+
+// PolicyResult is the outcome of evaluating a policy against the issues of a report
+type PolicyResult struct {
+	Status     PolicyStatus       `json:"status"`
+	Source     PolicySource       `json:"source"`
+	Violations []*PolicyViolation `json:"violations,omitempty"`
+}
+
+// PolicyViolation is a clause matched by more issues than allowed
+type PolicyViolation struct {
+	Clause   string `json:"clause"`
+	Count    int    `json:"count"`
+	MaxCount int    `json:"max_count"`
+}
+
+// Failed - check if the policy failed, reports without a policy pass
+func (r *PolicyResult) Failed() bool {
+	return r != nil && r.Status == PolicyFail
+}
+
+// SelectPolicy - select the policy of a repository, or else the global policy
+func SelectPolicy(repo *Repository, global *app.Policy) (*app.Policy, PolicySource) {
+	if repo != nil && repo.Policy != nil {
+		return repo.Policy, PolicySourceRepository
+	}
+	return global, PolicySourceGlobal
+}
+
+// EvaluatePolicy - evaluate every clause of a policy against the issues of a report,
+// issues are expected to have their baseline status and triage state applied.
+// No result is returned without a policy.
+func EvaluatePolicy(p *app.Policy, source PolicySource, issues []*Issue) (*PolicyResult, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	result := &PolicyResult{Status: PolicyPass, Source: source}
+	for _, clause := range p.Clauses {
+		count, err := countPolicyMatches(clause, issues)
+		if err != nil {
+			return nil, err
+		}
+
+		if count <= clause.MaxCount {
+			continue
+		}
+
+		result.Status = PolicyFail
+		result.Violations = append(result.Violations, &PolicyViolation{
+			Clause:   policyClauseName(clause),
+			Count:    count,
+			MaxCount: clause.MaxCount,
+		})
+	}
+
+	return result, nil
+}
+
+// countPolicyMatches - count the issues matching all conditions of a clause
+func countPolicyMatches(clause *app.PolicyClause, issues []*Issue) (int, error) {
+	var threshold Score
+	if clause.Severity != "" {
+		var err error
+		if threshold, err = ParseScore(clause.Severity); err != nil {
+			return 0, fmt.Errorf("policy clause %q: %w", policyClauseName(clause), err)
+		}
+	}
+
+	for _, pattern := range clause.ExcludePaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return 0, fmt.Errorf("policy clause %q: exclude path %q: %w", policyClauseName(clause), pattern, err)
+		}
+	}
+
+	count := 0
+	for _, issue := range issues {
+		if issue.Triage != nil &&
+			(issue.Triage.State == TriageFalsePositive || issue.Triage.State == TriageAcceptedRisk) {
+			continue
+		}
+
+		if clause.NewOnly && issue.Status == IssueStatusBaselined {
+			continue
+		}
+
+		if clause.RuleID != "" && !strings.EqualFold(clause.RuleID, issue.RuleID) {
+			continue
+		}
+
+		if threshold > 0 {
+			score, err := ParseScore(issue.Severity)
+			if err != nil || score < threshold {
+				continue
+			}
+		}
+
+		if matchAnyPath(clause.ExcludePaths, issue.Location.Path) {
+			continue
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// matchAnyPath - check if a path matches any of the patterns. Patterns ending with a slash
+// match a directory at any depth, or only at the repository root with a leading slash,
+// patterns without a slash match the file name, other patterns match the whole path.
+func matchAnyPath(patterns []string, p string) bool {
+	p = strings.TrimPrefix(p, "/")
+
+	for _, pattern := range patterns {
+		anchored := strings.HasPrefix(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(p, pattern) || (!anchored && strings.Contains(p, "/"+pattern)) {
+				return true
+			}
+			continue
+		}
+
+		name := p
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(p)
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// policyClauseName - get the name of a clause, or describe its conditions when unnamed
+func policyClauseName(clause *app.PolicyClause) string {
+	if clause.Name != "" {
+		return clause.Name
+	}
+
+	parts := []string{fmt.Sprintf("max_count=%d", clause.MaxCount)}
+	if clause.Severity != "" {
+		parts = append(parts, "severity>="+strings.ToUpper(clause.Severity))
+	}
+	if clause.RuleID != "" {
+		parts = append(parts, "rule="+clause.RuleID)
+	}
+	if clause.NewOnly {
+		parts = append(parts, "new_only")
+	}
+	if len(clause.ExcludePaths) > 0 {
+		parts = append(parts, "exclude="+strings.Join(clause.ExcludePaths, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
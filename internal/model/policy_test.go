@@ -0,0 +1,100 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	issues := []*model.Issue{
+		{RuleID: "G001", Severity: "HIGH", Status: model.IssueStatusBaselined, Location: model.Location{Path: "/config/prod.yaml"}},
+		{RuleID: "G002", Severity: "MEDIUM", Status: model.IssueStatusActive, Location: model.Location{Path: "/main.go"}},
+		{RuleID: "G002", Severity: "MEDIUM", Status: model.IssueStatusActive, Location: model.Location{Path: "/main_test.go"}},
+		{RuleID: "G010", Severity: "LOW", Status: model.IssueStatusActive, Location: model.Location{Path: "/test/fixtures/key.pem"}},
+		{RuleID: "G010", Severity: "LOW", Status: model.IssueStatusActive, Location: model.Location{Path: "/internal/test/key.pem"}},
+		{
+			RuleID: "G010", Severity: "HIGH", Status: model.IssueStatusActive, Location: model.Location{Path: "/deploy/key.pem"},
+			Triage: &model.IssueTriage{State: model.TriageFalsePositive},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		clause     *app.PolicyClause
+		violations int
+	}{
+		{"any high", &app.PolicyClause{Severity: "HIGH"}, 1},
+		{"any new high", &app.PolicyClause{Severity: "HIGH", NewOnly: true}, 0},
+		{"more than one new medium or higher", &app.PolicyClause{Severity: "medium", NewOnly: true, MaxCount: 1}, 2},
+		{"more than two new medium or higher", &app.PolicyClause{Severity: "MEDIUM", NewOnly: true, MaxCount: 2}, 0},
+		{"rule outside tests", &app.PolicyClause{RuleID: "G010", ExcludePaths: []string{"test/"}}, 0},
+		{"rule outside root tests", &app.PolicyClause{RuleID: "g010", ExcludePaths: []string{"/test/"}}, 1},
+		{"rule outside test files", &app.PolicyClause{RuleID: "G002", ExcludePaths: []string{"*_test.go"}}, 1},
+		{"rule outside fixtures", &app.PolicyClause{RuleID: "G010", ExcludePaths: []string{"test/fixtures/*.pem"}}, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := model.EvaluatePolicy(&app.Policy{Clauses: []*app.PolicyClause{tc.clause}},
+				model.PolicySourceGlobal, issues)
+			assert.NoError(t, err)
+			assert.Equal(t, model.PolicySourceGlobal, result.Source)
+
+			if tc.violations == 0 {
+				assert.Equal(t, model.PolicyPass, result.Status)
+				assert.False(t, result.Failed())
+				assert.Empty(t, result.Violations)
+				return
+			}
+
+			assert.Equal(t, model.PolicyFail, result.Status)
+			assert.True(t, result.Failed())
+			assert.Len(t, result.Violations, 1)
+			assert.Equal(t, tc.violations, result.Violations[0].Count)
+			assert.Equal(t, tc.clause.MaxCount, result.Violations[0].MaxCount)
+		})
+	}
+}
+
+func TestEvaluatePolicyClauseName(t *testing.T) {
+	result, err := model.EvaluatePolicy(&app.Policy{Clauses: []*app.PolicyClause{
+		{Name: "no secrets", RuleID: "G001"},
+		{Severity: "medium", NewOnly: true, MaxCount: 0, RuleID: "G001", ExcludePaths: []string{"test/", "*_test.go"}},
+	}}, model.PolicySourceRepository, []*model.Issue{{RuleID: "G001", Severity: "HIGH"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.PolicyViolation{
+		{Clause: "no secrets", Count: 1},
+		{Clause: "max_count=0 severity>=MEDIUM rule=G001 new_only exclude=test/,*_test.go", Count: 1},
+	}, result.Violations)
+}
+
+func TestEvaluatePolicyInvalid(t *testing.T) {
+	result, err := model.EvaluatePolicy(nil, model.PolicySourceGlobal, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.False(t, result.Failed())
+
+	_, err = model.EvaluatePolicy(&app.Policy{Clauses: []*app.PolicyClause{{Severity: "CRITICAL"}}},
+		model.PolicySourceGlobal, nil)
+	assert.ErrorContains(t, err, "severity>=CRITICAL")
+
+	_, err = model.EvaluatePolicy(&app.Policy{Clauses: []*app.PolicyClause{{ExcludePaths: []string{"[test"}}}},
+		model.PolicySourceGlobal, nil)
+	assert.ErrorContains(t, err, `exclude path "[test"`)
+}
+
+func TestSelectPolicy(t *testing.T) {
+	global := &app.Policy{Clauses: []*app.PolicyClause{{Severity: "HIGH"}}}
+	own := &app.Policy{}
+
+	p, source := model.SelectPolicy(&model.Repository{}, global)
+	assert.Equal(t, global, p)
+	assert.Equal(t, model.PolicySourceGlobal, source)
+
+	p, source = model.SelectPolicy(&model.Repository{Policy: own}, global)
+	assert.Same(t, own, p)
+	assert.Equal(t, model.PolicySourceRepository, source)
+}
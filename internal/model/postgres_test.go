@@ -0,0 +1,120 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/cmd/database/migrations"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"github.com/uptrace/bun/migrate"
+)
+
+// startPostgresDB - open a postgres database in a schema of its own, it is dropped
+// at the end of the test. The test is skipped when TEST_POSTGRES_DSN is not set.
+func startPostgresDB(t *testing.T) *bun.DB {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is required to run postgres tests")
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("gitsast_test_%d", time.Now().UnixNano())
+
+	admin := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+	t.Cleanup(func() { admin.Close() })
+
+	_, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_, err := admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		assert.NoError(t, err)
+	})
+
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(
+		pgdriver.WithDSN(dsn),
+		pgdriver.WithConnParams(map[string]interface{}{"search_path": schema}),
+	)), pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestPostgresLegacyIssuesMigration(t *testing.T) {
+	db := startPostgresDB(t)
+	ctx := context.Background()
+
+	// the tables created by db init before migrations, issues were a jsonb column of reports
+	for _, query := range []string{
+		`CREATE TABLE "rules" (
+			"id" BIGSERIAL NOT NULL, "name" VARCHAR, "keyword" VARCHAR, "description" VARCHAR, "severity" BIGINT,
+			"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp, "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+			PRIMARY KEY ("id")
+		)`,
+		`CREATE TABLE "repositories" (
+			"id" VARCHAR NOT NULL, "name" VARCHAR, "remote_url" VARCHAR,
+			"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp, "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+			PRIMARY KEY ("id")
+		)`,
+		`CREATE TABLE "reports" (
+			"id" VARCHAR NOT NULL, "repository_id" uuid, "status" VARCHAR DEFAULT 'initialized',
+			"created_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp, "updated_at" TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+			"enqueue_at" TIMESTAMPTZ, "started_at" TIMESTAMPTZ, "finished_at" TIMESTAMPTZ, "failed_reason" VARCHAR, "issues" jsonb,
+			PRIMARY KEY ("id")
+		)`,
+		`INSERT INTO "reports" ("id", "repository_id", "status", "issues") VALUES
+			('2f0e3a5c-8a52-4f5b-9d0e-7d2d6c1f4b11', 'f0d8368d-85e2-54fb-73c4-2d60374295e3', 'success', '[
+				{"ruleId": "2", "location": {"path": "config/secrets.yml", "line": 12}, "description": "A secret starts with the prefix private_key", "severity": "HIGH", "keyword": "private_key"},
+				{"ruleId": "1", "location": {"path": "main.go", "line": 3}, "description": "A secret starts with the prefix public_key", "severity": "LOW", "keyword": "public_key"}
+			]'),
+			('5b7c1d2e-3f40-4a5b-8c6d-7e8f9a0b1c2d', 'f0d8368d-85e2-54fb-73c4-2d60374295e3', 'success', '[]'),
+			('7d2d6c1f-4b11-4f5b-9d0e-2f0e3a5c8a52', 'f0d8368d-85e2-54fb-73c4-2d60374295e3', 'failed', NULL)`,
+	} {
+		_, err := db.ExecContext(ctx, query)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	migrator := migrate.NewMigrator(db, migrations.Postgres)
+	assert.NoError(t, migrator.Init(ctx))
+	_, err := migrator.Migrate(ctx)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var issues []*model.Issue
+	err = db.NewSelect().Model(&issues).Order("issue.id ASC").Scan(ctx)
+	assert.NoError(t, err)
+
+	// the issues are copied in the order of the legacy array
+	if assert.Len(t, issues, 2) {
+		assert.Equal(t, "2f0e3a5c-8a52-4f5b-9d0e-7d2d6c1f4b11", issues[0].ReportID)
+		assert.Equal(t, "2", issues[0].RuleID)
+		assert.Equal(t, model.Location{Path: "config/secrets.yml", Line: 12}, issues[0].Location)
+		assert.Equal(t, "HIGH", issues[0].Severity)
+		assert.Equal(t, "private_key", issues[0].Keyword)
+		assert.Equal(t, model.IssueStatusActive, issues[0].Status)
+		assert.Equal(t, 1, issues[0].Occurrences)
+		assert.Equal(t, "main.go", issues[1].Location.Path)
+	}
+
+	// the legacy column is dropped once its issues are copied
+	exists, err := db.NewSelect().
+		TableExpr("information_schema.columns").
+		Where("table_schema = current_schema()").
+		Where("table_name = 'reports'").
+		Where("column_name = 'issues'").
+		Exists(ctx)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// ProgressStage is the stage of a running scan
+type ProgressStage string
+
+const (
+	ProgressQueued    ProgressStage = "queued"
+	ProgressCloning   ProgressStage = "cloning"
+	ProgressScanning  ProgressStage = "scanning"
+	ProgressFinished  ProgressStage = "finished"
+	ProgressFailed    ProgressStage = "failed"
+	ProgressCancelled ProgressStage = "cancelled"
+)
+
+// IsDone - check if no more progress is going to be published after this stage
+func (s ProgressStage) IsDone() bool {
+	return s == ProgressFinished || s == ProgressFailed || s == ProgressCancelled
+}
+
+// Progress holds the progress of a scan, published as events while the scan is running
+type Progress struct {
+	ReportID        string        `json:"report_id"`
+	Stage           ProgressStage `json:"stage"`
+	FilesDiscovered int           `json:"files_discovered"`
+	FilesScanned    int           `json:"files_scanned"`
+	IssuesFound     int           `json:"issues_found"`
+	Percent         int           `json:"percent"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// SetStage - move the progress to a new stage, a finished scan is complete
+func (p *Progress) SetStage(stage ProgressStage, now time.Time) {
+	p.Stage = stage
+	p.UpdatedAt = now
+	if stage == ProgressFinished {
+		p.Percent = 100
+	}
+}
+
+// SetScanned - update the number of scanned files and issues found so far
+func (p *Progress) SetScanned(filesScanned, issuesFound int, now time.Time) {
+	p.FilesScanned = filesScanned
+	p.IssuesFound = issuesFound
+	p.UpdatedAt = now
+	if p.FilesDiscovered > 0 {
+		p.Percent = filesScanned * 100 / p.FilesDiscovered
+	}
+}
+
+// CurrentProgress - return the progress of the report, derived from the
+// report status when no progress was recorded
+func (r *Report) CurrentProgress() *Progress {
+	if r.Progress != nil {
+		return r.Progress
+	}
+
+	p := &Progress{ReportID: r.ID, UpdatedAt: r.UpdatedAt}
+	switch r.Status {
+	case StatusInProgress:
+		p.Stage = ProgressCloning
+	case StatusSuccess:
+		p.Stage = ProgressFinished
+		p.Percent = 100
+	case StatusFailed:
+		p.Stage = ProgressFailed
+	case StatusCancelled:
+		p.Stage = ProgressCancelled
+	default:
+		p.Stage = ProgressQueued
+	}
+
+	return p
+}
@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	p := &model.Progress{ReportID: "fake-uuid", FilesDiscovered: 3}
+	p.SetStage(model.ProgressScanning, now)
+	p.SetScanned(1, 2, now)
+	assert.Equal(t, 33, p.Percent)
+	assert.Equal(t, 2, p.IssuesFound)
+	assert.False(t, p.Stage.IsDone())
+
+	p.SetStage(model.ProgressFinished, now)
+	assert.Equal(t, 100, p.Percent)
+	assert.True(t, p.Stage.IsDone())
+}
+
+func TestReportCurrentProgress(t *testing.T) {
+	testCases := []struct {
+		status model.ReportStatus
+		stage  model.ProgressStage
+	}{
+		{model.StatusInitialized, model.ProgressQueued},
+		{model.StatusEnqueued, model.ProgressQueued},
+		{model.StatusInProgress, model.ProgressCloning},
+		{model.StatusSuccess, model.ProgressFinished},
+		{model.StatusFailed, model.ProgressFailed},
+		{model.StatusCancelled, model.ProgressCancelled},
+	}
+
+	for _, tc := range testCases {
+		r := &model.Report{ID: "fake-uuid", Status: tc.status}
+		assert.Equal(t, tc.stage, r.CurrentProgress().Stage, tc.status)
+	}
+
+	scanning := &model.Progress{Stage: model.ProgressScanning}
+	r := &model.Report{Status: model.StatusInProgress, Progress: scanning}
+	assert.Equal(t, scanning, r.CurrentProgress())
+}
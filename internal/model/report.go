@@ -2,11 +2,11 @@ package model
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/marktrs/gitsast/app"
 	"github.com/uptrace/bun"
-	"github.com/uptrace/bun/dialect/pgdialect"
 )
 
 type Report struct {
@@ -21,8 +21,24 @@ type Report struct {
 	StartedAt    time.Time    `json:"started_at,omitempty"`
 	FinishedAt   time.Time    `json:"finished_at,omitempty"`
 	FailedReason string       `json:"failed_reason,omitempty"`
+	// FailureKind classifies the failed reason, reports failing with a transient failure
+	// are retried until they run out of attempts
+	FailureKind FailureKind `json:"failure_kind,omitempty"`
+	// Attempts is the number of times the scan of the report started
+	Attempts int `json:"attempts"`
 
-	Issues []*Issue `json:"issues,omitempty" bun:"type:jsonb"`
+	// Options are the scan options used for this report
+	Options    *ScanOptions `json:"options,omitempty"`
+	Submodules []*Submodule `json:"submodules,omitempty"`
+	// Progress is the latest progress of the scan, see also GET /reports/:id/events
+	Progress *Progress `json:"progress,omitempty"`
+	// Summary holds the statistics of the scan, see also GET /reports/:id/summary
+	Summary *Summary `json:"summary,omitempty"`
+	// PolicyResult tells whether the scan passed the policy of its repository, empty without a policy
+	PolicyResult *PolicyResult `json:"policy_result,omitempty"`
+
+	// Issues are stored in the issues table, see IReportRepo.GetIssues
+	Issues []*Issue `json:"issues,omitempty" bun:"rel:has-many,join:id=report_id"`
 }
 
 type ReportStatus string
@@ -33,17 +49,91 @@ const (
 	StatusInProgress  ReportStatus = "in-progress"
 	StatusSuccess     ReportStatus = "success"
 	StatusFailed      ReportStatus = "failed"
+	StatusCancelled   ReportStatus = "cancelled"
+)
+
+// FailureKind is the class of error failing a scan
+type FailureKind string
+
+const (
+	// FailureNetwork is a network error reaching the git host
+	FailureNetwork FailureKind = "network"
+	// FailureCloneTimeout is a clone exceeding the scan deadline
+	FailureCloneTimeout FailureKind = "clone_timeout"
+	// FailureDatabase is an error reading or storing data of the scan
+	FailureDatabase FailureKind = "database"
+	// FailureAuth is a remote refusing the credentials of the repository
+	FailureAuth FailureKind = "auth"
+	// FailureNotFound is a missing or empty remote repository
+	FailureNotFound FailureKind = "not_found"
+	// FailureInvalidRef is a missing branch or reference of the repository
+	FailureInvalidRef FailureKind = "invalid_ref"
+	// FailureScanTimeout is a scan exceeding its deadline after the clone
+	FailureScanTimeout FailureKind = "scan_timeout"
+	// FailureInternal is any other error
+	FailureInternal FailureKind = "internal"
 )
 
+// IsTransient - check if a scan failing with this kind of failure may succeed when retried
+func (k FailureKind) IsTransient() bool {
+	return k == FailureNetwork || k == FailureCloneTimeout || k == FailureDatabase
+}
+
+// ReportResponse is a report with its issues converted into findings, see the json exporter
+type ReportResponse struct {
+	Report
+	Findings []*Finding `json:"findings"`
+}
+
+// IsFinished - check if no worker is going to pick up or update the report anymore
+func (s ReportStatus) IsFinished() bool {
+	return s == StatusSuccess || s == StatusFailed || s == StatusCancelled
+}
+
+// IsCancellable - check if the scan of the report is queued or running
+func (s ReportStatus) IsCancellable() bool {
+	return s == StatusInitialized || s == StatusEnqueued || s == StatusInProgress
+}
+
+// previous - return the statuses a report may change to this status from,
+// finished reports are never changed again
+func (s ReportStatus) previous() []ReportStatus {
+	switch s {
+	case StatusEnqueued:
+		// queued again when its scan is interrupted or retried
+		return []ReportStatus{StatusInitialized, StatusEnqueued, StatusInProgress}
+	case StatusInProgress:
+		// a task delivered again once its worker stopped finds the report in progress
+		return []ReportStatus{StatusEnqueued, StatusInProgress}
+	case StatusSuccess:
+		return []ReportStatus{StatusInProgress}
+	case StatusFailed:
+		return []ReportStatus{StatusInitialized, StatusEnqueued, StatusInProgress}
+	case StatusCancelled:
+		return []ReportStatus{StatusInitialized, StatusEnqueued, StatusInProgress}
+	}
+	return nil
+}
+
 // IReport defines methods for read/write reports table.
 type IReportRepo interface {
 	GetById(ctx context.Context, id string) (*Report, error)
-	GetByRepoId(ctx context.Context, id string) (*Report, error)
+	GetLatestByRepoId(ctx context.Context, repoId string) (*Report, error)
+	GetLatestSuccessfulByRepoId(ctx context.Context, repoId string) (*Report, error)
+	ListByRepoId(ctx context.Context, repoId string, f *ReportListFilter) ([]*Report, int, error)
 	Update(ctx context.Context, report *Report) (*Report, error)
+	UpdateStatus(ctx context.Context, report *Report) (*Report, error)
+	Cancel(ctx context.Context, id string, now time.Time) error
+	UpdateProgress(ctx context.Context, id string, progress *Progress) error
 	Add(ctx context.Context, report *Report) (*Report, error)
 	GetIssues(ctx context.Context, reportID string) ([]*Issue, error)
+	ListIssues(ctx context.Context, reportID string, f *IssueFilter) ([]*Issue, error)
+	AddIssues(ctx context.Context, reportID string, issues []*Issue) error
 }
 
+// issueInsertBatchSize is the maximum number of issues inserted by a single query
+const issueInsertBatchSize = 1000
+
 type ReportRepo struct {
 	app *app.App
 }
@@ -65,10 +155,28 @@ func (r *ReportRepo) GetById(ctx context.Context, id string) (*Report, error) {
 	return report, nil
 }
 
-func (r *ReportRepo) GetByRepoId(ctx context.Context, id string) (*Report, error) {
+// GetLatestByRepoId - get the most recently created report of a repository, whatever its status
+func (r *ReportRepo) GetLatestByRepoId(ctx context.Context, repoId string) (*Report, error) {
+	report := &Report{}
+	err := r.app.DB().NewSelect().Model(report).
+		Where("repository_id = ?", repoId).
+		OrderExpr("created_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetLatestSuccessfulByRepoId - get the most recently created successful report of a repository
+func (r *ReportRepo) GetLatestSuccessfulByRepoId(ctx context.Context, repoId string) (*Report, error) {
 	report := &Report{}
 	err := r.app.DB().NewSelect().Model(report).
-		Where("repository_id = ?", id).
+		Where("repository_id = ?", repoId).
+		Where("status = ?", StatusSuccess).
+		OrderExpr("created_at DESC").
 		Limit(1).
 		Scan(ctx)
 	if err != nil {
@@ -78,6 +186,23 @@ func (r *ReportRepo) GetByRepoId(ctx context.Context, id string) (*Report, error
 	return report, nil
 }
 
+// ListByRepoId - list reports of a repository and the total count of matching reports
+func (r *ReportRepo) ListByRepoId(ctx context.Context, repoId string, f *ReportListFilter) ([]*Report, int, error) {
+	reports := []*Report{}
+	total, err := r.app.DB().NewSelect().
+		Model(&reports).
+		Where("repository_id = ?", repoId).
+		Apply(f.query).
+		Limit(f.Limit).
+		Offset(f.Offset).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
 func (r *ReportRepo) Add(ctx context.Context, report *Report) (*Report, error) {
 	_, err := r.app.DB().NewInsert().Model(report).Exec(ctx)
 	if err != nil {
@@ -96,17 +221,121 @@ func (r *ReportRepo) Update(ctx context.Context, report *Report) (*Report, error
 	return report, nil
 }
 
-// GetReportIssues - get all issues for report
+// UpdateStatus - update the report when its stored status may change to the status of the report,
+// ErrReportStatusChanged is returned when it was changed meanwhile, such as cancelled
+func (r *ReportRepo) UpdateStatus(ctx context.Context, report *Report) (*Report, error) {
+	res, err := r.app.DB().NewUpdate().Model(report).WherePK().
+		Where("status IN (?)", bun.In(report.Status.previous())).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := expectUpdated(res, ErrReportStatusChanged); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Cancel - set a queued or running report to cancelled, only its status and timestamps are
+// written. ErrReportNotCancellable is returned when the report is finished.
+func (r *ReportRepo) Cancel(ctx context.Context, id string, now time.Time) error {
+	res, err := r.app.DB().NewUpdate().Model((*Report)(nil)).
+		Set("status = ?", StatusCancelled).
+		Set("updated_at = ?", now).
+		Set("finished_at = ?", now).
+		Where("id = ?", id).
+		Where("status IN (?)", bun.In(StatusCancelled.previous())).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return expectUpdated(res, ErrReportNotCancellable)
+}
+
+// expectUpdated - return errNotUpdated when the query updated no row
+func expectUpdated(res sql.Result, errNotUpdated error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return errNotUpdated
+	}
+	return nil
+}
+
+// UpdateProgress - update only the progress of a report, the status is left untouched
+func (r *ReportRepo) UpdateProgress(ctx context.Context, id string, progress *Progress) error {
+	_, err := r.app.DB().NewUpdate().Model((*Report)(nil)).
+		Set("progress = ?", progress).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+// GetIssues - get all issues of a report
 func (r *ReportRepo) GetIssues(ctx context.Context, reportID string) ([]*Issue, error) {
-	var issues []*Issue
+	issues := []*Issue{}
+	err := r.app.DB().NewSelect().
+		Model(&issues).
+		Where("report_id = ?", reportID).
+		OrderExpr("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// ListIssues - list a page of issues of a report matching the filter, ordered by ID
+func (r *ReportRepo) ListIssues(ctx context.Context, reportID string, f *IssueFilter) ([]*Issue, error) {
+	issues := []*Issue{}
 	err := r.app.DB().NewSelect().
-		Model((*Report)(nil)).
-		ColumnExpr("issues").
-		Where("id = ?", reportID).
-		Scan(ctx, pgdialect.Array(&issues))
+		Model(&issues).
+		Where("report_id = ?", reportID).
+		Apply(f.query).
+		Limit(f.Limit).
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	return issues, nil
 }
+
+// AddIssues - replace the issues of a report, they are inserted in batches within a single
+// transaction. Issues stored by an earlier attempt of the scan are deleted first.
+func (r *ReportRepo) AddIssues(ctx context.Context, reportID string, issues []*Issue) error {
+	for _, issue := range issues {
+		issue.ReportID = reportID
+	}
+
+	return r.app.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*Issue)(nil)).
+			Where("report_id = ?", reportID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(issues); start += issueInsertBatchSize {
+			end := start + issueInsertBatchSize
+			if end > len(issues) {
+				end = len(issues)
+			}
+
+			batch := issues[start:end]
+			if _, err := tx.NewInsert().Model(&batch).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
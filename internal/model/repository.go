@@ -17,7 +17,14 @@ type Repository struct {
 	CreatedAt time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
 	UpdatedAt time.Time `json:"updated_at" bun:",nullzero,notnull,default:current_timestamp"`
 
-	Report *Report `json:"report,omitempty" bun:"rel:has-one,join:id=repository_id"`
+	// ScanOptions are the default options for every scan of this repository
+	ScanOptions *ScanOptions `json:"scan_options,omitempty"`
+	// Baseline is the uploaded baseline of known findings, merged with the baseline file of the repository
+	Baseline *Baseline `json:"baseline,omitempty"`
+	// Policy passes or fails the scans of this repository, instead of the global policy
+	Policy *app.Policy `json:"policy,omitempty"`
+
+	Reports []*Report `json:"reports,omitempty" bun:"rel:has-many,join:id=repository_id"`
 }
 
 // IRepository defines methods for read/write repositories table.
@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/marktrs/gitsast/app"
@@ -46,6 +47,19 @@ func (c Score) String() string {
 	return "UNDEFINED"
 }
 
+// ParseScore - convert a severity string such as "HIGH" into a Score
+func ParseScore(s string) (Score, error) {
+	switch strings.ToUpper(s) {
+	case "HIGH":
+		return High, nil
+	case "MEDIUM":
+		return Medium, nil
+	case "LOW":
+		return Low, nil
+	}
+	return 0, fmt.Errorf("invalid severity: %q", s)
+}
+
 // GetFormattedRuleId - return a formatted rule ID
 func GetFormattedRuleId(id uint64) string {
 	return "G" + fmt.Sprintf("%03d", id)
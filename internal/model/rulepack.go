@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRules - the built-in rule pack, inserted by db init and used by standalone scans
+func DefaultRules() []*Rule {
+	return []*Rule{
+		{
+			ID:          1,
+			Name:        "Public key leak",
+			Description: "A secret starts with the prefix public_key",
+			Keyword:     "public_key",
+			Severity:    Low,
+		},
+		{
+			ID:          2,
+			Name:        "Private key leak",
+			Description: "A secret starts with the prefix private_key",
+			Keyword:     "private_key",
+			Severity:    High,
+		},
+	}
+}
+
+// rulesFile is a YAML or JSON rule pack, severities are written as names such as HIGH
+type rulesFile struct {
+	Rules []struct {
+		ID          uint64 `yaml:"id"`
+		Name        string `yaml:"name"`
+		Keyword     string `yaml:"keyword"`
+		Description string `yaml:"description"`
+		Severity    string `yaml:"severity"`
+	} `yaml:"rules"`
+}
+
+// ParseRules - parse a YAML or JSON rule pack, rules without ID are numbered after their position
+func ParseRules(b []byte) ([]*Rule, error) {
+	var f rulesFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	if len(f.Rules) == 0 {
+		return nil, errors.New("rules file has no rules")
+	}
+
+	rules := make([]*Rule, 0, len(f.Rules))
+	seen := make(map[uint64]bool, len(f.Rules))
+	for i, r := range f.Rules {
+		id := r.ID
+		if id == 0 {
+			id = uint64(i + 1)
+		}
+
+		if seen[id] {
+			return nil, fmt.Errorf("rule %d: duplicate ID %s", i+1, GetFormattedRuleId(id))
+		}
+		seen[id] = true
+
+		if r.Keyword == "" {
+			return nil, fmt.Errorf("rule %d: keyword is required", i+1)
+		}
+
+		// keywords are matched as regular expressions by the detector
+		if _, err := regexp.Compile(r.Keyword); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+
+		severity, err := ParseScore(r.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+
+		rules = append(rules, &Rule{
+			ID:          id,
+			Name:        r.Name,
+			Keyword:     r.Keyword,
+			Description: r.Description,
+			Severity:    severity,
+		})
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := model.ParseRules([]byte(`
+rules:
+  - name: AWS access key
+    keyword: AKIA[0-9A-Z]{16}
+    severity: high
+  - id: 10
+    name: Slack token
+    keyword: xox[baprs]-
+    description: A Slack token
+    severity: MEDIUM
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Rule{
+		{ID: 1, Name: "AWS access key", Keyword: "AKIA[0-9A-Z]{16}", Severity: model.High},
+		{ID: 10, Name: "Slack token", Keyword: "xox[baprs]-", Description: "A Slack token", Severity: model.Medium},
+	}, rules)
+
+	// JSON is valid YAML
+	rules, err = model.ParseRules([]byte(`{"rules": [{"keyword": "private_key", "severity": "LOW"}]}`))
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, model.Low, rules[0].Severity)
+}
+
+func TestParseRulesInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		file string
+		err  string
+	}{
+		{"empty", `rules: []`, "rules file has no rules"},
+		{"missing keyword", `rules: [{severity: HIGH}]`, "rule 1: keyword is required"},
+		{"invalid keyword", `rules: [{keyword: "(", severity: HIGH}]`, "rule 1: error parsing regexp"},
+		{"invalid severity", `rules: [{keyword: key, severity: CRITICAL}]`, `rule 1: invalid severity: "CRITICAL"`},
+		{"duplicate ID", `rules: [{id: 2, keyword: a, severity: LOW}, {keyword: b, severity: LOW}]`, "rule 2: duplicate ID G002"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := model.ParseRules([]byte(tc.file))
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+// MaxSubmoduleDepth - maximum depth of nested submodules that can be scanned
+const MaxSubmoduleDepth = 10
+
+// ScanOptions holds options used by the analyzer when scanning a repository
+type ScanOptions struct {
+	// Submodules enables initializing and scanning git submodules recursively
+	Submodules bool `json:"submodules"`
+	// SubmoduleDepth limits how deep nested submodules are scanned,
+	// 1 scans only the submodules of the repository itself
+	SubmoduleDepth int `json:"submodule_depth,omitempty" validate:"submodule-depth"`
+}
+
+// GetSubmoduleDepth - return the submodule depth to scan, 0 when submodules are disabled
+func (o *ScanOptions) GetSubmoduleDepth() int {
+	if o == nil || !o.Submodules {
+		return 0
+	}
+
+	if o.SubmoduleDepth <= 0 {
+		return 1
+	}
+
+	if o.SubmoduleDepth > MaxSubmoduleDepth {
+		return MaxSubmoduleDepth
+	}
+
+	return o.SubmoduleDepth
+}
+
+// Submodule describes a git submodule scanned along with its parent repository
+type Submodule struct {
+	// Path is the submodule path relative to the root of the scanned repository
+	Path      string `json:"path"`
+	URL       string `json:"url"`
+	CommitSHA string `json:"commit_sha"`
+	Depth     int    `json:"depth"`
+}
@@ -0,0 +1,303 @@
+package model_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/cmd/database/migrations"
+	"github.com/marktrs/gitsast/internal/model"
+	mocks "github.com/marktrs/gitsast/testutil/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun/migrate"
+)
+
+// the test config stores data in a sqlite database living as long as the test app
+func startSQLiteApp(t *testing.T) *mocks.TestApp {
+	testApp := mocks.StartTestApp(context.Background())
+	t.Cleanup(testApp.Stop)
+
+	assert.Equal(t, app.DialectSQLite, testApp.Config().DB.GetDialect())
+
+	migrator := migrate.NewMigrator(testApp.DB(), migrations.SQLite)
+	assert.NoError(t, migrator.Init(context.Background()))
+	_, err := migrator.Migrate(context.Background())
+	assert.NoError(t, err)
+
+	return testApp
+}
+
+// startSQLiteAppBefore - start a test app with the sqlite migrations older than version applied,
+// seed fills the database before the remaining migrations are applied
+func startSQLiteAppBefore(t *testing.T, version string, seed func(ctx context.Context, testApp *mocks.TestApp)) {
+	testApp := mocks.StartTestApp(context.Background())
+	t.Cleanup(testApp.Stop)
+	ctx := context.Background()
+
+	files := fstest.MapFS{}
+	dir := os.DirFS("../../cmd/database/migrations/sqlite")
+	entries, err := fs.ReadDir(dir, ".")
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Name() >= version {
+			continue
+		}
+
+		data, err := fs.ReadFile(dir, entry.Name())
+		assert.NoError(t, err)
+		files[entry.Name()] = &fstest.MapFile{Data: data}
+	}
+
+	older := migrate.NewMigrations()
+	assert.NoError(t, older.Discover(files))
+
+	migrator := migrate.NewMigrator(testApp.DB(), older)
+	assert.NoError(t, migrator.Init(ctx))
+	_, err = migrator.Migrate(ctx)
+	assert.NoError(t, err)
+
+	seed(ctx, testApp)
+
+	_, err = migrate.NewMigrator(testApp.DB(), migrations.SQLite).Migrate(ctx)
+	assert.NoError(t, err)
+}
+
+func TestSQLiteRepositories(t *testing.T) {
+	testApp := startSQLiteApp(t)
+	ctx := context.Background()
+	repos := model.NewRepositoryRepo(testApp.App)
+
+	repo, err := repos.Add(ctx, &model.Repository{
+		ID:          "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Name:        "gitsast",
+		RemoteURL:   "https://github.com/marktrs/gitsast.git",
+		ScanOptions: &model.ScanOptions{Submodules: true, SubmoduleDepth: 2},
+		Policy:      &app.Policy{Clauses: []*app.PolicyClause{{Severity: "HIGH"}}},
+	})
+	assert.NoError(t, err)
+
+	// JSON documents are read back from text columns
+	got, err := repos.GetById(ctx, repo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.ScanOptions{Submodules: true, SubmoduleDepth: 2}, got.ScanOptions)
+	assert.Equal(t, "HIGH", got.Policy.Clauses[0].Severity)
+	assert.Nil(t, got.Baseline)
+	assert.False(t, got.CreatedAt.IsZero())
+}
+
+func TestSQLiteReportIssues(t *testing.T) {
+	testApp := startSQLiteApp(t)
+	ctx := context.Background()
+	reports := model.NewReportRepo(testApp.App)
+
+	report, err := reports.Add(ctx, &model.Report{
+		ID:           "2f0e3a5c-8a52-4f5b-9d0e-7d2d6c1f4b11",
+		RepositoryID: "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Status:       model.StatusInProgress,
+		Progress:     &model.Progress{Stage: model.ProgressScanning, Percent: 40},
+	})
+	assert.NoError(t, err)
+
+	issues := []*model.Issue{
+		{RuleID: "G001", Severity: "HIGH", Location: model.Location{Path: "/config/app.yaml", Line: 1}},
+		{RuleID: "G002", Severity: "LOW", Location: model.Location{Path: "/Config/app.yaml", Line: 2}, Status: model.IssueStatusBaselined},
+		{
+			RuleID:   "G001",
+			Severity: "HIGH",
+			Location: model.Location{Path: "/config_test.go", Line: 3},
+			Commit:   &model.Commit{SHA: "8f3b2c1", Date: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	assert.NoError(t, reports.AddIssues(ctx, report.ID, issues))
+
+	all, err := reports.GetIssues(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	assert.Equal(t, "8f3b2c1", all[2].Commit.SHA)
+	assert.True(t, all[2].Commit.Date.Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)))
+
+	// path prefixes are case sensitive and their wildcards are escaped like on postgres
+	page, err := reports.ListIssues(ctx, report.ID, &model.IssueFilter{PathPrefix: "/config/", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "/config/app.yaml", page[0].Location.Path)
+
+	page, err = reports.ListIssues(ctx, report.ID, &model.IssueFilter{PathPrefix: "/config_", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "/config_test.go", page[0].Location.Path)
+
+	page, err = reports.ListIssues(ctx, report.ID, &model.IssueFilter{Severity: "HIGH", Cursor: all[0].ID, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, all[2].ID, page[0].ID)
+
+	page, err = reports.ListIssues(ctx, report.ID, &model.IssueFilter{Status: model.IssueStatusBaselined, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "G002", page[0].RuleID)
+
+	// the issues of an earlier attempt of the scan are replaced
+	assert.NoError(t, reports.AddIssues(ctx, report.ID, []*model.Issue{
+		{RuleID: "G002", Severity: "LOW", Location: model.Location{Path: "/Config/app.yaml", Line: 2}},
+	}))
+
+	retried, err := reports.GetIssues(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Len(t, retried, 1)
+	assert.Equal(t, "G002", retried[0].RuleID)
+
+	// a retry finding no issues clears them
+	assert.NoError(t, reports.AddIssues(ctx, report.ID, nil))
+
+	retried, err = reports.GetIssues(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, retried)
+
+	report.Attempts = 2
+	report.FailureKind = model.FailureNetwork
+	_, err = reports.Update(ctx, report)
+	assert.NoError(t, err)
+
+	assert.NoError(t, reports.UpdateProgress(ctx, report.ID, &model.Progress{Stage: model.ProgressFinished, Percent: 100}))
+
+	got, err := reports.GetById(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.ProgressFinished, got.Progress.Stage)
+	assert.Equal(t, 2, got.Attempts)
+	assert.Equal(t, model.FailureNetwork, got.FailureKind)
+}
+
+func TestSQLiteReportStatus(t *testing.T) {
+	testApp := startSQLiteApp(t)
+	ctx := context.Background()
+	reports := model.NewReportRepo(testApp.App)
+
+	report, err := reports.Add(ctx, &model.Report{
+		ID:           "5b7c1d2e-3f40-4a5b-8c6d-7e8f9a0b1c2d",
+		RepositoryID: "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Status:       model.StatusEnqueued,
+	})
+	assert.NoError(t, err)
+
+	report.Status = model.StatusInProgress
+	_, err = reports.UpdateStatus(ctx, report)
+	assert.NoError(t, err)
+
+	assert.NoError(t, reports.UpdateProgress(ctx, report.ID, &model.Progress{Stage: model.ProgressScanning, Percent: 60}))
+	assert.NoError(t, reports.Cancel(ctx, report.ID, time.Now()))
+
+	// the cancelled status is kept and the progress is not overwritten
+	report.Status = model.StatusSuccess
+	_, err = reports.UpdateStatus(ctx, report)
+	assert.ErrorIs(t, err, model.ErrReportStatusChanged)
+
+	got, err := reports.GetById(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusCancelled, got.Status)
+	assert.Equal(t, 60, got.Progress.Percent)
+	assert.False(t, got.FinishedAt.IsZero())
+
+	assert.ErrorIs(t, reports.Cancel(ctx, report.ID, time.Now()), model.ErrReportNotCancellable)
+}
+
+func TestSQLiteTriageUpsert(t *testing.T) {
+	testApp := startSQLiteApp(t)
+	ctx := context.Background()
+	triages := model.NewTriageRepo(testApp.App)
+
+	triage := &model.Triage{
+		RepositoryID: "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Fingerprint:  "a1b2",
+		State:        model.TriageConfirmed,
+	}
+	_, err := triages.Upsert(ctx, triage)
+	assert.NoError(t, err)
+
+	triage.State = model.TriageFalsePositive
+	triage.Comments = []*model.TriageComment{{Author: "alice", Body: "test fixture"}}
+	_, err = triages.Upsert(ctx, triage)
+	assert.NoError(t, err)
+
+	got, err := triages.GetByRepoId(ctx, triage.RepositoryID)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, model.TriageFalsePositive, got[0].State)
+	assert.Equal(t, "test fixture", got[0].Comments[0].Body)
+}
+
+func TestSQLiteTriageComments(t *testing.T) {
+	testApp := startSQLiteApp(t)
+	ctx := context.Background()
+	triages := model.NewTriageRepo(testApp.App)
+
+	// a comment on a finding which was never triaged opens its triage
+	assert.NoError(t, triages.AddComment(ctx, &model.TriageComment{
+		RepositoryID: "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Fingerprint:  "a1b2",
+		Author:       "alice",
+		Body:         "first",
+	}))
+
+	stale, err := triages.Get(ctx, "f0d8368d-85e2-54fb-73c4-2d60374295e3", "a1b2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.TriageOpen, stale.State)
+	assert.Len(t, stale.Comments, 1)
+
+	// a comment added while the triage is updated from a stale copy is kept
+	assert.NoError(t, triages.AddComment(ctx, &model.TriageComment{
+		RepositoryID: "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Fingerprint:  "a1b2",
+		Author:       "bob",
+		Body:         "second",
+	}))
+
+	stale.State = model.TriageConfirmed
+	stale.Comments = append(stale.Comments, &model.TriageComment{Author: "carol", Body: "third"})
+	_, err = triages.Upsert(ctx, stale)
+	assert.NoError(t, err)
+
+	got, err := triages.Get(ctx, "f0d8368d-85e2-54fb-73c4-2d60374295e3", "a1b2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.TriageConfirmed, got.State)
+
+	bodies := make([]string, 0, len(got.Comments))
+	for _, c := range got.Comments {
+		bodies = append(bodies, c.Body)
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, bodies)
+}
+
+func TestSQLiteTriageCommentsMigration(t *testing.T) {
+	var triages model.ITriageRepo
+
+	startSQLiteAppBefore(t, "20261021000000", func(ctx context.Context, testApp *mocks.TestApp) {
+		triages = model.NewTriageRepo(testApp.App)
+
+		// comments of older releases are stored as a JSON array of the triage
+		_, err := testApp.DB().ExecContext(ctx, strings.Join([]string{
+			`INSERT INTO finding_triage (repository_id, fingerprint, state, comments) VALUES`,
+			`('f0d8368d-85e2-54fb-73c4-2d60374295e3', 'a1b2', 'confirmed',`,
+			`'[{"author":"alice","body":"first","created_at":"2020-01-01T00:00:00Z"},{"author":"bob","body":"second","created_at":"2020-01-02T00:00:00Z"}]'),`,
+			`('f0d8368d-85e2-54fb-73c4-2d60374295e3', 'c3d4', 'open', 'null')`,
+		}, " "))
+		assert.NoError(t, err)
+	})
+
+	got, err := triages.Get(context.Background(), "f0d8368d-85e2-54fb-73c4-2d60374295e3", "a1b2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.TriageConfirmed, got.State)
+	assert.Len(t, got.Comments, 2)
+	assert.Equal(t, "alice", got.Comments[0].Author)
+	assert.Equal(t, "second", got.Comments[1].Body)
+	assert.True(t, got.Comments[1].CreatedAt.Equal(time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)))
+
+	got, err = triages.Get(context.Background(), "f0d8368d-85e2-54fb-73c4-2d60374295e3", "c3d4")
+	assert.NoError(t, err)
+	assert.Empty(t, got.Comments)
+}
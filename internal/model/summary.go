@@ -0,0 +1,66 @@
+package model
+
+// SkipReason tells why a file was not scanned
+type SkipReason string
+
+const (
+	// SkipIgnoredFileType is a file left out by its extension, such as images or archives
+	SkipIgnoredFileType SkipReason = "ignored_file_type"
+	// SkipBinary is a file detected as binary from its content
+	SkipBinary SkipReason = "binary"
+)
+
+// Summary holds the statistics of a report, it is persisted with the report
+// so dashboards get per repository numbers without loading issues
+type Summary struct {
+	// CommitSHA is the HEAD commit of the scanned repository
+	CommitSHA string `json:"commit_sha,omitempty"`
+
+	TotalIssues      int            `json:"total_issues"`
+	IssuesBySeverity map[string]int `json:"issues_by_severity"`
+	IssuesByRule     map[string]int `json:"issues_by_rule"`
+
+	FilesDiscovered int                `json:"files_discovered"`
+	FilesScanned    int                `json:"files_scanned"`
+	FilesSkipped    int                `json:"files_skipped"`
+	SkipReasons     map[SkipReason]int `json:"skip_reasons,omitempty"`
+	BytesScanned    int64              `json:"bytes_scanned"`
+
+	CloneDurationMS int64 `json:"clone_duration_ms"`
+	ScanDurationMS  int64 `json:"scan_duration_ms"`
+}
+
+// NewSummary - create an empty summary
+func NewSummary() *Summary {
+	return &Summary{
+		IssuesBySeverity: make(map[string]int),
+		IssuesByRule:     make(map[string]int),
+		SkipReasons:      make(map[SkipReason]int),
+	}
+}
+
+// Skip - count files skipped for the given reason
+func (s *Summary) Skip(reason SkipReason, files int) {
+	if files <= 0 {
+		return
+	}
+
+	if s.SkipReasons == nil {
+		s.SkipReasons = make(map[SkipReason]int)
+	}
+
+	s.SkipReasons[reason] += files
+	s.FilesSkipped += files
+}
+
+// CountIssues - count the issues of a report by severity and rule
+func (s *Summary) CountIssues(issues []*Issue) {
+	s.TotalIssues = len(issues)
+	s.IssuesBySeverity = make(map[string]int)
+	s.IssuesByRule = make(map[string]int)
+
+	for _, issue := range issues {
+		s.IssuesBySeverity[issue.Severity]++
+		s.IssuesByRule[issue.RuleID]++
+	}
+}
@@ -0,0 +1,26 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	s := &model.Summary{}
+	s.Skip(model.SkipIgnoredFileType, 3)
+	s.Skip(model.SkipBinary, 1)
+	s.Skip(model.SkipBinary, 0)
+	assert.Equal(t, 4, s.FilesSkipped)
+	assert.Equal(t, map[model.SkipReason]int{model.SkipIgnoredFileType: 3, model.SkipBinary: 1}, s.SkipReasons)
+
+	s.CountIssues([]*model.Issue{
+		{RuleID: "G001", Severity: "HIGH"},
+		{RuleID: "G001", Severity: "HIGH"},
+		{RuleID: "G002", Severity: "LOW"},
+	})
+	assert.Equal(t, 3, s.TotalIssues)
+	assert.Equal(t, map[string]int{"HIGH": 2, "LOW": 1}, s.IssuesBySeverity)
+	assert.Equal(t, map[string]int{"G001": 2, "G002": 1}, s.IssuesByRule)
+}
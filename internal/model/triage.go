@@ -0,0 +1,211 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/uptrace/bun"
+)
+
+// Triage holds the triage decision of a finding, identified by its fingerprint within a repository
+type Triage struct {
+	bun.BaseModel `bun:"table:finding_triage,alias:triage"`
+
+	RepositoryID string           `json:"repository_id" bun:",pk,type:uuid"`
+	Fingerprint  string           `json:"fingerprint" bun:",pk"`
+	State        TriageState      `json:"state" bun:",notnull,default:'open'"`
+	Assignee     string           `json:"assignee,omitempty"`
+	ExpiresAt    time.Time        `json:"expires_at,omitempty" bun:",nullzero"`
+	Comments     []*TriageComment `json:"comments" bun:"rel:has-many,join:repository_id=repository_id,join:fingerprint=fingerprint"`
+	CreatedAt    time.Time        `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt    time.Time        `json:"updated_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+type TriageState string
+
+const (
+	TriageOpen          TriageState = "open"
+	TriageConfirmed     TriageState = "confirmed"
+	TriageFalsePositive TriageState = "false_positive"
+	TriageAcceptedRisk  TriageState = "accepted_risk"
+	TriageFixed         TriageState = "fixed"
+)
+
+// IsValid - check if the triage state is one of the known states
+func (s TriageState) IsValid() bool {
+	switch s {
+	case TriageOpen, TriageConfirmed, TriageFalsePositive, TriageAcceptedRisk, TriageFixed:
+		return true
+	}
+	return false
+}
+
+// TriageComment is a comment of the triage discussion of a finding, comments are stored
+// in their own table and only ever inserted so that concurrent comments are all kept
+type TriageComment struct {
+	bun.BaseModel `bun:"table:triage_comments,alias:triage_comment"`
+
+	ID           int64     `json:"-" bun:",pk,autoincrement"`
+	RepositoryID string    `json:"-" bun:",notnull,type:uuid"`
+	Fingerprint  string    `json:"-" bun:",notnull"`
+	Author       string    `json:"author"`
+	Body         string    `json:"body"`
+	CreatedAt    time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// IssueTriage is the triage state of an issue carried forward into reports
+type IssueTriage struct {
+	State     TriageState `json:"state"`
+	Assignee  string      `json:"assignee,omitempty"`
+	ExpiresAt time.Time   `json:"expires_at,omitempty"`
+}
+
+// EffectiveState - return the triage state at the given time, expired decisions are open again
+func (t *Triage) EffectiveState(now time.Time) TriageState {
+	if !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt) {
+		return TriageOpen
+	}
+
+	return t.State
+}
+
+// ApplyTriages - attach the effective triage state of each issue by fingerprint,
+// issues without triage decision are open
+func ApplyTriages(issues []*Issue, triages []*Triage, now time.Time) {
+	byFingerprint := make(map[string]*Triage, len(triages))
+	for _, t := range triages {
+		byFingerprint[t.Fingerprint] = t
+	}
+
+	for _, issue := range issues {
+		t, ok := byFingerprint[issue.Fingerprint]
+		if !ok || issue.Fingerprint == "" {
+			issue.Triage = &IssueTriage{State: TriageOpen}
+			continue
+		}
+
+		issue.Triage = &IssueTriage{
+			State:     t.EffectiveState(now),
+			Assignee:  t.Assignee,
+			ExpiresAt: t.ExpiresAt,
+		}
+	}
+}
+
+// ITriageRepo defines methods for read/write finding_triage table.
+type ITriageRepo interface {
+	Get(ctx context.Context, repoId string, fingerprint string) (*Triage, error)
+	GetByRepoId(ctx context.Context, repoId string) ([]*Triage, error)
+	Upsert(ctx context.Context, triage *Triage) (*Triage, error)
+	AddComment(ctx context.Context, comment *TriageComment) error
+}
+
+type TriageRepo struct {
+	app *app.App
+}
+
+// NewTriageRepo returns a new instance of TriageRepo.
+func NewTriageRepo(app *app.App) ITriageRepo {
+	return &TriageRepo{app}
+}
+
+// Get - returns the triage of a finding in a repository.
+func (r *TriageRepo) Get(ctx context.Context, repoId string, fingerprint string) (*Triage, error) {
+	triage := &Triage{}
+	err := r.app.DB().NewSelect().Model(triage).
+		Relation("Comments", orderComments).
+		Where("repository_id = ?", repoId).
+		Where("fingerprint = ?", fingerprint).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return triage, nil
+}
+
+// GetByRepoId - returns all triages of a repository.
+func (r *TriageRepo) GetByRepoId(ctx context.Context, repoId string) ([]*Triage, error) {
+	triages := []*Triage{}
+	err := r.app.DB().NewSelect().Model(&triages).
+		Relation("Comments", orderComments).
+		Where("repository_id = ?", repoId).
+		Order("updated_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return triages, nil
+}
+
+// Upsert - creates or replaces the triage decision of a finding, the comments of the
+// triage which are not stored yet are added with it
+func (r *TriageRepo) Upsert(ctx context.Context, triage *Triage) (*Triage, error) {
+	err := r.app.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(triage).
+			On("CONFLICT (repository_id, fingerprint) DO UPDATE").
+			Set("state = EXCLUDED.state").
+			Set("assignee = EXCLUDED.assignee").
+			Set("expires_at = EXCLUDED.expires_at").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, comment := range triage.Comments {
+			if comment.ID != 0 {
+				continue
+			}
+
+			comment.RepositoryID = triage.RepositoryID
+			comment.Fingerprint = triage.Fingerprint
+			if err := insertComment(ctx, tx, comment); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return triage, nil
+}
+
+// AddComment - add a comment to the triage of a finding without touching its decision,
+// a finding which was never triaged gets an open triage
+func (r *TriageRepo) AddComment(ctx context.Context, comment *TriageComment) error {
+	return r.app.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		triage := &Triage{
+			RepositoryID: comment.RepositoryID,
+			Fingerprint:  comment.Fingerprint,
+			State:        TriageOpen,
+			CreatedAt:    comment.CreatedAt,
+			UpdatedAt:    comment.CreatedAt,
+		}
+
+		_, err := tx.NewInsert().Model(triage).
+			On("CONFLICT (repository_id, fingerprint) DO UPDATE").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return insertComment(ctx, tx, comment)
+	})
+}
+
+func insertComment(ctx context.Context, tx bun.Tx, comment *TriageComment) error {
+	_, err := tx.NewInsert().Model(comment).Returning("id").Exec(ctx)
+	return err
+}
+
+// orderComments - list the comments of a triage in the order they were added
+func orderComments(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.OrderExpr("triage_comment.id ASC")
+}
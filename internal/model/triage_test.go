@@ -0,0 +1,41 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriageEffectiveState(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	triage := &model.Triage{State: model.TriageAcceptedRisk}
+	assert.Equal(t, model.TriageAcceptedRisk, triage.EffectiveState(now))
+
+	triage.ExpiresAt = now.Add(time.Hour)
+	assert.Equal(t, model.TriageAcceptedRisk, triage.EffectiveState(now))
+
+	triage.ExpiresAt = now
+	assert.Equal(t, model.TriageOpen, triage.EffectiveState(now))
+}
+
+func TestApplyTriages(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	issues := []*model.Issue{
+		{RuleID: "G001", Fingerprint: "confirmed"},
+		{RuleID: "G001", Fingerprint: "expired"},
+		{RuleID: "G002", Fingerprint: "untriaged"},
+	}
+
+	model.ApplyTriages(issues, []*model.Triage{
+		{Fingerprint: "confirmed", State: model.TriageConfirmed, Assignee: "jane"},
+		{Fingerprint: "expired", State: model.TriageAcceptedRisk, ExpiresAt: now.Add(-time.Hour)},
+	}, now)
+
+	assert.Equal(t, model.TriageConfirmed, issues[0].Triage.State)
+	assert.Equal(t, "jane", issues[0].Triage.Assignee)
+	assert.Equal(t, model.TriageOpen, issues[1].Triage.State)
+	assert.Equal(t, model.TriageOpen, issues[2].Triage.State)
+}
@@ -0,0 +1,94 @@
+package progress
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+// IBroker variable that does static check to make sure that 'broker' struct implements 'IBroker' interface.
+var _ IBroker = (*broker)(nil)
+
+// IBroker defines methods to publish and subscribe to progress events of a report
+type IBroker interface {
+	Publish(ctx context.Context, progress *model.Progress) error
+	Subscribe(ctx context.Context, reportId string) (<-chan *model.Progress, error)
+}
+
+type broker struct {
+	redis *redis.Client
+}
+
+// NewBroker - create a progress broker using redis pub/sub, or relaying events in memory
+// when scans are queued in memory since they run in the same process
+func NewBroker(app *app.App) IBroker {
+	if app.Config().Queue.IsMemory() {
+		return memoryBrokerOf(app)
+	}
+
+	return &broker{app.Redis()}
+}
+
+// channel - return the pub/sub channel of a report
+func channel(reportId string) string {
+	return "gitsast:reports:" + reportId + ":progress"
+}
+
+// Publish - publish a progress event to subscribers of the report
+func (b *broker) Publish(ctx context.Context, progress *model.Progress) error {
+	payload, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	return b.redis.Publish(ctx, channel(progress.ReportID), payload).Err()
+}
+
+// Subscribe - subscribe to progress events of a report until the context is done,
+// the returned channel is closed when the subscription ends
+func (b *broker) Subscribe(ctx context.Context, reportId string) (<-chan *model.Progress, error) {
+	sub := b.redis.Subscribe(ctx, channel(reportId))
+
+	// wait for the subscription to be confirmed so no event is missed afterward
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	events := make(chan *model.Progress)
+
+	go func() {
+		defer close(events)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var progress model.Progress
+				if err := json.Unmarshal([]byte(msg.Payload), &progress); err != nil {
+					log.Err(err).Str("report_id", reportId).Msg("unable to decode progress event")
+					continue
+				}
+
+				select {
+				case events <- &progress:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,113 @@
+package progress
+
+import (
+	"context"
+	"sync"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+// memoryBufferSize is the number of events held for a subscriber,
+// events published while it is full are dropped like redis does for slow subscribers,
+// except for the last event of a scan which replaces the oldest held event
+const memoryBufferSize = 100
+
+var _ IBroker = (*memoryBroker)(nil)
+
+// memoryBrokers holds the memory broker of each app, brokers are shared by the
+// tasks publishing progress and the handlers subscribing to it
+var memoryBrokers sync.Map
+
+type memoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *model.Progress]struct{}
+}
+
+// memoryBrokerOf - return the memory broker of the app, it is created on first use
+func memoryBrokerOf(a *app.App) *memoryBroker {
+	b, loaded := memoryBrokers.LoadOrStore(a, &memoryBroker{
+		subscribers: make(map[string]map[chan *model.Progress]struct{}),
+	})
+	if !loaded {
+		a.OnAfterStop("progress", func(ctx context.Context, app *app.App) error {
+			memoryBrokers.Delete(app)
+			return nil
+		})
+	}
+
+	return b.(*memoryBroker)
+}
+
+// Publish - send a copy of the progress event to subscribers of the report
+func (b *memoryBroker) Publish(ctx context.Context, progress *model.Progress) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subscribers[progress.ReportID] {
+		event := *progress
+
+		select {
+		case events <- &event:
+			continue
+		default:
+		}
+
+		if !event.Stage.IsDone() {
+			log.Warn().Str("report_id", progress.ReportID).Msg("dropping progress event of slow subscriber")
+			continue
+		}
+
+		// the subscriber waits for the last event of the scan to end its stream
+		deliverLast(events, &event)
+	}
+
+	return nil
+}
+
+// deliverLast - send the last event of a scan to a full subscriber by dropping its oldest
+// held events, the lock of the broker is held so no other event is sent to it meanwhile
+func deliverLast(events chan *model.Progress, event *model.Progress) {
+	for {
+		select {
+		case events <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-events:
+			log.Warn().Str("report_id", event.ReportID).Msg("dropping progress event of slow subscriber")
+		default:
+		}
+	}
+}
+
+// Subscribe - subscribe to progress events of a report until the context is done,
+// the returned channel is closed when the subscription ends
+func (b *memoryBroker) Subscribe(ctx context.Context, reportId string) (<-chan *model.Progress, error) {
+	events := make(chan *model.Progress, memoryBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[reportId] == nil {
+		b.subscribers[reportId] = make(map[chan *model.Progress]struct{})
+	}
+	b.subscribers[reportId][events] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subscribers[reportId], events)
+		if len(b.subscribers[reportId]) == 0 {
+			delete(b.subscribers, reportId)
+		}
+		close(events)
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,74 @@
+package progress_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/progress"
+	mocks "github.com/marktrs/gitsast/testutil/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	testApp := mocks.StartTestApp(context.Background())
+	defer testApp.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// brokers of the same app share their subscriptions
+	events, err := progress.NewBroker(testApp.App).Subscribe(ctx, "report-1")
+	assert.NoError(t, err)
+
+	publisher := progress.NewBroker(testApp.App)
+	assert.NoError(t, publisher.Publish(ctx, &model.Progress{ReportID: "report-2", Stage: model.ProgressScanning}))
+	assert.NoError(t, publisher.Publish(ctx, &model.Progress{ReportID: "report-1", Stage: model.ProgressFinished, Percent: 100}))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "report-1", event.ReportID)
+		assert.Equal(t, model.ProgressFinished, event.Stage)
+	case <-time.After(time.Second):
+		assert.Fail(t, "no progress event received")
+	}
+
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		_, ok := <-events
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMemoryBrokerSlowSubscriber(t *testing.T) {
+	testApp := mocks.StartTestApp(context.Background())
+	defer testApp.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := progress.NewBroker(testApp.App)
+	events, err := broker.Subscribe(ctx, "report-1")
+	assert.NoError(t, err)
+
+	// the subscriber does not read while the buffer overflows
+	for i := 0; i < 150; i++ {
+		assert.NoError(t, broker.Publish(ctx, &model.Progress{ReportID: "report-1", Stage: model.ProgressScanning, FilesScanned: i}))
+	}
+	assert.NoError(t, broker.Publish(ctx, &model.Progress{ReportID: "report-1", Stage: model.ProgressCancelled}))
+
+	var last *model.Progress
+	received := 0
+	for len(events) > 0 {
+		last = <-events
+		received++
+	}
+
+	// the last event of the scan is delivered in place of the oldest held event
+	assert.Equal(t, 100, received)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, model.ProgressCancelled, last.Stage)
+	}
+}
@@ -2,72 +2,207 @@ package queue
 
 import (
 	"context"
-	"os"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/go-redis/redis/v8"
 
 	"github.com/vmihailenco/taskq/v3"
+	"github.com/vmihailenco/taskq/v3/memqueue"
 	"github.com/vmihailenco/taskq/v3/redisq"
 )
 
+const (
+	// interruptTimeout is how long interrupted tasks are given to re-queue themselves
+	// once the shutdown timeout of the consumer elapsed
+	interruptTimeout = 10 * time.Second
+
+	// memoryBufferSize is the number of tasks the memory backend holds,
+	// adding a task blocks while it is full
+	memoryBufferSize = 1000
+	// memoryCloseTimeout is how long a closing memory queue waits for delayed tasks
+	memoryCloseTimeout = time.Second
+)
+
 type Handler interface {
-	StartConsumer() error
+	// StartConsumer - start consuming the queue with the given number of concurrent
+	// workers, tasks run with the given context. It returns once the consumer started.
+	StartConsumer(ctx context.Context, concurrency int) error
+	// StopConsumer - stop fetching tasks and wait up to timeout for running tasks,
+	// tasks still running afterwards are interrupted and re-queue themselves
+	StopConsumer(timeout time.Duration) error
 	AddTask(t *taskq.Message) error
+	// AddDeadLetter - park a task which ran out of retries in the dead-letter queue,
+	// tasks of the dead-letter queue are kept for inspection and not consumed
+	AddDeadLetter(t *taskq.Message) error
+	// Close - close the queue
+	Close() error
+}
+
+// Options holds the settings of a queue
+type Options struct {
+	// Name is the name of the queue
+	Name string
+	// DeadLetterName is the name of the queue holding the tasks which ran out of retries
+	DeadLetterName string
+	// Redis is the client of the redis backend, tasks are queued in memory when it is nil
+	Redis *redis.Client
+	// ReservationTimeout is how long a worker holds a task before it is delivered to another worker
+	ReservationTimeout time.Duration
+	// RetryLimit is the number of times a failing task runs before it is dropped and the fallback
+	// handler of the task runs, it applies to every registered task instead of the default limit of taskq
+	RetryLimit int
 }
 
 type handler struct {
-	redis        *redis.Client
-	queueFactory taskq.Factory
-	mainQueue    taskq.Queue
+	mainQueue       taskq.Queue
+	deadLetterQueue taskq.Queue
+	// closeQueue closes the queue of the backend
+	closeQueue func() error
+
+	// retryLimit is set on the registered tasks once the consumer starts
+	retryLimit int
+
+	// cancel interrupts the tasks run by the consumer
+	cancel context.CancelFunc
 }
 
-func NewHandler() Handler {
-	redis := redis.NewClient(&redis.Options{
-		Addr:       ":6379",
-		MaxRetries: 3,
-	})
+func NewHandler(opt *Options) Handler {
+	queueOpt := &taskq.QueueOptions{
+		Name: opt.Name,
+		// scans are long running, a worker only reserves the task it runs
+		// so that reserved tasks are not held back by a stopping worker
+		ReservationSize:    1,
+		ReservationTimeout: opt.ReservationTimeout,
+	}
 
+	deadLetterOpt := &taskq.QueueOptions{
+		Name:               opt.DeadLetterName,
+		ReservationSize:    1,
+		ReservationTimeout: opt.ReservationTimeout,
+	}
+
+	if opt.Redis == nil {
+		h := newMemoryHandler(queueOpt, deadLetterOpt)
+		h.retryLimit = opt.RetryLimit
+		return h
+	}
+
+	queueOpt.Redis = opt.Redis
+	deadLetterOpt.Redis = opt.Redis
+
+	// the redis client is shared with the app, which closes it
 	queueFactory := redisq.NewFactory()
+	mainQueue := queueFactory.RegisterQueue(queueOpt)
+	deadLetterQueue := queueFactory.RegisterQueue(deadLetterOpt)
+
+	return &handler{
+		mainQueue:       mainQueue,
+		deadLetterQueue: deadLetterQueue,
+		closeQueue:      queueFactory.Close,
+		retryLimit:      opt.RetryLimit,
+	}
+}
+
+// newMemoryHandler - create a handler queueing tasks in memory, they are only consumed
+// by this process and lost when it stops
+func newMemoryHandler(opt, deadLetterOpt *taskq.QueueOptions) *handler {
+	mainQueue := newMemoryQueue(opt)
+	deadLetterQueue := newMemoryQueue(deadLetterOpt)
+
+	closeQueue := func() error {
+		if n, _ := mainQueue.Len(); n > 0 {
+			log.Warn().Int("tasks", n).Msg("dropping tasks of memory queue")
+		}
+		if n, _ := deadLetterQueue.Len(); n > 0 {
+			log.Warn().Int("tasks", n).Msg("dropping tasks of memory dead-letter queue")
+		}
+
+		// delayed tasks are dropped as well
+		_ = mainQueue.CloseTimeout(memoryCloseTimeout)
+		_ = deadLetterQueue.CloseTimeout(memoryCloseTimeout)
+		return nil
+	}
 
-	mainQueue := queueFactory.RegisterQueue(&taskq.QueueOptions{
-		Name:  "main-queue",
-		Redis: redis,
-	})
+	return &handler{mainQueue: mainQueue, deadLetterQueue: deadLetterQueue, closeQueue: closeQueue}
+}
+
+// newMemoryQueue - create a memory queue which is not consumed until its consumer is started
+func newMemoryQueue(opt *taskq.QueueOptions) *memqueue.Queue {
+	opt.BufferSize = memoryBufferSize
+	opt.Storage = taskq.NewLocalStorage()
+
+	q := memqueue.NewQueue(opt)
 
-	return &handler{redis, queueFactory, mainQueue}
+	// a memory queue starts consuming right away, tasks wait in the buffer
+	// until they are run with the context given to StartConsumer
+	if err := q.Consumer().StopTimeout(memoryCloseTimeout); err != nil {
+		log.Err(err).Str("queue", opt.Name).Msg("unable to stop memory queue consumer")
+	}
+
+	return q
 }
 
 func (h *handler) AddTask(t *taskq.Message) error {
 	return h.mainQueue.Add(t)
 }
 
-func (h *handler) StartConsumer() error {
-	queueConsumerErr := make(chan error)
+func (h *handler) AddDeadLetter(t *taskq.Message) error {
+	return h.deadLetterQueue.Add(t)
+}
 
-	go func() {
-		queueConsumerErr <- h.queueFactory.StartConsumers(context.Background())
-	}()
+func (h *handler) StartConsumer(ctx context.Context, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-	shutdown := make(chan os.Signal, 1)
+	// the consumer reads the number of workers from the queue options when it starts
+	opt := h.mainQueue.Options()
+	opt.MinNumWorker = int32(concurrency)
+	opt.MaxNumWorker = int32(concurrency)
+
+	// tasks are registered globally with the retry limit of taskq, which is read
+	// by the consumer when a task fails
+	if h.retryLimit > 0 {
+		taskq.Tasks.Range(func(_ string, task *taskq.Task) bool {
+			task.Options().RetryLimit = h.retryLimit
+			return true
+		})
+	}
 
-	select {
-	case err := <-queueConsumerErr:
-		return err
-	case sig := <-shutdown:
-		log.Info().Any("signal", sig).Msg("stopping queue consumer with signal")
-		err := h.queueFactory.StopConsumers()
-		if err != nil {
-			return err
-		}
+	ctx, h.cancel = context.WithCancel(ctx)
 
-		err = h.queueFactory.Close()
-		if err != nil {
+	return h.mainQueue.Consumer().Start(ctx)
+}
+
+func (h *handler) StopConsumer(timeout time.Duration) error {
+	consumer := h.mainQueue.Consumer()
+
+	err := consumer.StopTimeout(timeout)
+	if err == nil {
+		log.Info().Msg("stopped queue consumer")
+		return nil
+	}
+
+	log.Warn().Err(err).Msg("interrupting running tasks")
+	if h.cancel != nil {
+		h.cancel()
+	}
+
+	// interrupted tasks are released back to the queue by the consumer
+	deadline := time.Now().Add(interruptTimeout)
+	for consumer.Stats().InFlight > 0 {
+		if time.Now().After(deadline) {
 			return err
 		}
-
-		log.Info().Any("signal", sig).Msg("stopped queue consumer with signal")
+		time.Sleep(100 * time.Millisecond)
 	}
+
+	log.Info().Msg("stopped queue consumer after interrupting running tasks")
 	return nil
 }
+
+func (h *handler) Close() error {
+	return h.closeQueue()
+}
@@ -0,0 +1,100 @@
+package queue_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+type ctxKey struct{}
+
+var (
+	processed atomic.Int32
+	// withConsumerCtx counts the tasks run with the context given to StartConsumer
+	withConsumerCtx atomic.Int32
+)
+
+var testTask = taskq.RegisterTask(&taskq.TaskOptions{
+	Name: "queue-test",
+	Handler: func(ctx context.Context) error {
+		if ctx.Value(ctxKey{}) != nil {
+			withConsumerCtx.Add(1)
+		}
+		processed.Add(1)
+		return nil
+	},
+})
+
+func TestMemoryHandler(t *testing.T) {
+	h := queue.NewHandler(&queue.Options{Name: "memory-test", DeadLetterName: "memory-test-dead-letter"})
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, h.AddTask(testTask.WithArgs(context.Background())))
+	}
+	// dead letters are never consumed
+	assert.NoError(t, h.AddDeadLetter(testTask.WithArgs(context.Background())))
+
+	// tasks wait for the consumer to start
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(0), processed.Load())
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, true)
+	assert.NoError(t, h.StartConsumer(ctx, 2))
+
+	assert.Eventually(t, func() bool {
+		return processed.Load() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(3), withConsumerCtx.Load())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(3), processed.Load())
+
+	assert.NoError(t, h.StopConsumer(time.Second))
+	assert.NoError(t, h.Close())
+}
+
+// retryError is retried shortly after
+type retryError struct{}
+
+func (retryError) Error() string { return "retry" }
+
+func (retryError) Delay() time.Duration { return 10 * time.Millisecond }
+
+var (
+	attempts atomic.Int32
+
+	failingTask = taskq.RegisterTask(&taskq.TaskOptions{
+		Name: "queue-retry-test",
+		Handler: func() error {
+			attempts.Add(1)
+			return retryError{}
+		},
+	})
+)
+
+func TestMemoryHandlerRetryLimit(t *testing.T) {
+	h := queue.NewHandler(&queue.Options{
+		Name:           "memory-retry-test",
+		DeadLetterName: "memory-retry-test-dead-letter",
+		RetryLimit:     3,
+	})
+
+	assert.NoError(t, h.StartConsumer(context.Background(), 1))
+	assert.NoError(t, h.AddTask(failingTask.WithArgs(context.Background())))
+
+	// the task is dropped after the retry limit instead of the default limit of taskq
+	assert.Eventually(t, func() bool {
+		return attempts.Load() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	assert.NoError(t, h.StopConsumer(time.Second))
+	assert.NoError(t, h.Close())
+}
@@ -2,12 +2,17 @@ package analyzer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/marktrs/gitsast/app"
 	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/progress"
 	"github.com/marktrs/gitsast/internal/queue/task/analyzer/git"
 	"github.com/rs/zerolog/log"
 	"github.com/vmihailenco/taskq/v3"
@@ -17,36 +22,77 @@ var _ IAnalyzeTask = (*Analyzer)(nil)
 
 var cloneLocationPrefix = "temp/"
 
+// TaskName is the name of the analyze task
+const TaskName = "analyzer"
+
+// requeueTimeout is the deadline of re-queuing a report once its task was interrupted
+const requeueTimeout = 10 * time.Second
+
+// ErrTaskDropped is the failure reason of reports whose task was delivered more times than
+// the queue allows, see Drop
+var ErrTaskDropped = errors.New("analyze task dropped by the queue after too many deliveries")
+
+// ErrInterrupted is returned by analyze tasks interrupted by a worker shutdown, the queue
+// retries them shortly after
+var ErrInterrupted error = interruptedError{}
+
+type interruptedError struct{}
+
+func (interruptedError) Error() string {
+	return "analyze task interrupted by worker shutdown"
+}
+
+// Delay implements taskq.Delayer, an interrupted task is retried without backoff
+func (interruptedError) Delay() time.Duration {
+	return time.Second
+}
+
 // Task - register analyze task into task queue
 var (
 	Task = taskq.RegisterTask(&taskq.TaskOptions{
-		Name: "analyzer",
-		Handler: func(reportId string) error {
-			_, app, err := app.Start(context.Background(), "analyzerTask", "")
+		Name: TaskName,
+		Handler: func(ctx context.Context, reportId string) error {
+			a, err := newTaskAnalyzer(ctx)
 			if err != nil {
 				return err
 			}
 
-			repo := model.NewRepositoryRepo(app)
-			report := model.NewReportRepo(app)
-			rule := model.NewRuleRepo(app)
-			git := git.NewClient()
-			detector := NewDetector()
-			scanner := NewScanner(detector)
-
-			a, err := NewAnalyzer(app, repo, report, rule, git, detector, scanner)
+			return a.Analyze(ctx, reportId)
+		},
+		// the queue drops a task once it was delivered queue.Options.RetryLimit times,
+		// e.g. a scan interrupted again and again, its report is failed instead
+		FallbackHandler: func(ctx context.Context, reportId string) error {
+			a, err := newTaskAnalyzer(ctx)
 			if err != nil {
 				return err
 			}
 
-			return a.Analyze(reportId)
+			return a.Drop(ctx, reportId)
 		},
 	})
 )
 
+// newTaskAnalyzer - create the analyzer of a queued task. Tasks run with the context given
+// to the queue consumer, which holds the app of the api or worker command consuming the queue
+func newTaskAnalyzer(ctx context.Context) (IAnalyzeTask, error) {
+	app := app.AppFromContext(ctx)
+
+	repo := model.NewRepositoryRepo(app)
+	report := model.NewReportRepo(app)
+	rule := model.NewRuleRepo(app)
+	triage := model.NewTriageRepo(app)
+	broker := progress.NewBroker(app)
+	git := git.NewClient()
+	detector := NewDetector()
+	scanner := NewScanner(detector, app.Config().Scan.GetFileTimeout())
+
+	return NewAnalyzer(app, repo, report, rule, triage, broker, git, detector, scanner)
+}
+
 // IAnalyzeTask - interface for analyze task
 type IAnalyzeTask interface {
-	Analyze(reportId string) error
+	Analyze(ctx context.Context, reportId string) error
+	Drop(ctx context.Context, reportId string) error
 }
 
 type Analyzer struct {
@@ -54,6 +100,8 @@ type Analyzer struct {
 	repo     model.IRepositoryRepo
 	report   model.IReportRepo
 	rule     model.IRuleRepo
+	triage   model.ITriageRepo
+	broker   progress.IBroker
 	git      git.IClient
 	detector Detector
 	scanner  Scanner
@@ -65,18 +113,19 @@ func NewAnalyzer(
 	repo model.IRepositoryRepo,
 	report model.IReportRepo,
 	rule model.IRuleRepo,
+	triage model.ITriageRepo,
+	broker progress.IBroker,
 	git git.IClient,
 	detector Detector,
 	scanner Scanner,
 
 ) (IAnalyzeTask, error) {
 
-	return &Analyzer{app, repo, report, rule, git, detector, scanner}, nil
+	return &Analyzer{app, repo, report, rule, triage, broker, git, detector, scanner}, nil
 }
 
 // Analyze - implement analyze task interface
-func (a *Analyzer) Analyze(reportId string) error {
-	ctx := context.Background()
+func (a *Analyzer) Analyze(ctx context.Context, reportId string) error {
 	log := log.Info().Fields(map[string]interface{}{
 		"report_id": reportId,
 	})
@@ -89,57 +138,273 @@ func (a *Analyzer) Analyze(reportId string) error {
 		return err
 	}
 
+	// the task of a report cancelled while queued is dropped
+	if report.Status == model.StatusCancelled {
+		log.Msg("report was cancelled, skipping analyze task")
+		publishProgress(ctx, a.broker, report.CurrentProgress())
+		return nil
+	}
+
 	report.StartedAt = time.Now()
 
 	// set status
 	log.Msg("set status to in_progress")
-	if err := a.setReportStatus(report, model.StatusInProgress); err != nil {
-		return a.handleFailedTask(report, err)
+	if err := a.setReportStatus(ctx, report, model.StatusInProgress); err != nil {
+		if errors.Is(err, model.ErrReportStatusChanged) {
+			log.Msg("report was cancelled, skipping analyze task")
+			return nil
+		}
+		return a.handleFailedTask(ctx, report, storage(err))
+	}
+
+	// the deadline covers the whole scan, report status updates use the parent context
+	// so a timed out report can still be marked as failed
+	timeout := a.app.Config().Scan.GetTimeout()
+	scanCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// stop scanning once the report is cancelled, the temp directory is removed by scan
+	var cancelled atomic.Bool
+	stopWatching := a.watchCancellation(scanCtx, report.ID, cancel, &cancelled)
+	err = a.scan(scanCtx, report)
+	stopWatching()
+
+	if cancelled.Load() {
+		log.Msg("analyze task cancelled")
+		report.Progress.SetStage(model.ProgressCancelled, time.Now())
+		newProgressTracker(a.report, a.broker, report.Progress).update(ctx, true)
+		return nil
+	}
+
+	if err != nil {
+		// the worker is shutting down, the scan starts over once the task is retried
+		if ctx.Err() != nil {
+			return a.requeue(report)
+		}
+
+		if errors.Is(scanCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: scan exceeded the deadline of %s", model.ErrScanTimeout, timeout)
+		}
+		return a.handleFailedTask(ctx, report, err)
+	}
+
+	// failures of previous attempts are cleared once the scan succeeded
+	report.FinishedAt = time.Now()
+	report.FailedReason = ""
+	report.FailureKind = ""
+	if err := a.setReportStatus(ctx, report, model.StatusSuccess); err != nil {
+		// the report was cancelled after the last cancellation poll
+		if errors.Is(err, model.ErrReportStatusChanged) {
+			log.Msg("report was cancelled, discarding scan result")
+			return nil
+		}
+		return a.handleFailedTask(ctx, report, storage(err))
+	}
+	log.Msg("analyzed task completed")
+
+	return nil
+}
+
+// watchCancellation - poll the report status in background and cancel the scan
+// once the report is cancelled, the returned function stops polling
+func (a *Analyzer) watchCancellation(
+	ctx context.Context,
+	reportId string,
+	cancel context.CancelFunc,
+	cancelled *atomic.Bool,
+) func() {
+	ctx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(a.app.Config().Scan.GetCancelPollInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := a.report.GetById(ctx, reportId)
+				if err != nil {
+					log.Err(err).Str("report_id", reportId).Msg("unable to poll report status")
+					continue
+				}
+
+				if report.Status == model.StatusCancelled {
+					cancelled.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		stop()
+		<-done
 	}
+}
+
+// scan - clone the repository of a report and scan it for issues
+func (a *Analyzer) scan(ctx context.Context, report *model.Report) error {
+	log := log.Info().Fields(map[string]interface{}{
+		"report_id": report.ID,
+	})
 
 	log.Str("repository_id", report.RepositoryID).Msg("get repository by id")
 	repo, err := a.repo.GetById(ctx, report.RepositoryID)
 	if err != nil {
-		return a.handleFailedTask(report, err)
+		return storage(err)
 	}
 
 	// look up for latest rules
 	rules, err := a.rule.GetAll(ctx)
 	if err != nil {
-		return a.handleFailedTask(report, err)
+		return storage(err)
+	}
+
+	// scan options of the report take precedence over the repository defaults
+	opts := report.Options
+	if opts == nil {
+		opts = repo.ScanOptions
 	}
 
 	tmpDir := path.Join(cloneLocationPrefix, repo.ID)
 
+	// remove partial clones as well when the clone fails or times out
+	defer a.removeTempDir(tmpDir)
+
+	// the summary is stored with failed reports as well, up to the failed stage
+	summary := model.NewSummary()
+	report.Summary = summary
+
 	log.Str("url", repo.RemoteURL).Msg("getting paths from remote url")
-	paths, err := a.git.GetPathsFromRemoteURL(tmpDir, repo.RemoteURL)
+	cloneStart := time.Now()
+	checkout, err := a.git.GetPathsFromRemoteURL(ctx, tmpDir, repo.RemoteURL, &git.CloneOptions{
+		SubmoduleDepth: opts.GetSubmoduleDepth(),
+	})
+	summary.CloneDurationMS = time.Since(cloneStart).Milliseconds()
 	if err != nil {
-		return a.handleFailedTask(report, err)
+		return err
 	}
-	defer a.removeTempDir(tmpDir)
+
+	report.Submodules = checkout.Submodules
+	summary.CommitSHA = checkout.CommitSHA
+	summary.FilesDiscovered = len(checkout.Paths) + checkout.Ignored
+	summary.Skip(model.SkipIgnoredFileType, checkout.Ignored)
+
+	tracker := newProgressTracker(a.report, a.broker, report.Progress)
+	report.Progress.FilesDiscovered = len(checkout.Paths)
+	report.Progress.SetStage(model.ProgressScanning, time.Now())
+	tracker.update(ctx, true)
 
 	log.Str("url", repo.RemoteURL).Msg("scanning files for issues")
-	issues, err := a.scanner.ScanFilesForIssues(repo.ID, paths, rules)
+	scanStart := time.Now()
+	issues, stats, err := a.scanner.ScanFilesForIssues(ctx, tmpDir, checkout.Paths, rules,
+		func(filesScanned, issuesFound int) {
+			report.Progress.SetScanned(filesScanned, issuesFound, time.Now())
+			tracker.update(ctx, false)
+		})
+	summary.ScanDurationMS = time.Since(scanStart).Milliseconds()
+	if stats != nil {
+		summary.FilesScanned = stats.FilesScanned
+		summary.BytesScanned = stats.BytesScanned
+		for reason, files := range stats.FilesSkipped {
+			summary.Skip(reason, files)
+		}
+	}
 	if err != nil {
-		return a.handleFailedTask(report, err)
+		return err
 	}
 
 	if len(issues) == 0 {
 		log.Msg("no issues found")
 	} else {
 		log.Msg("adding issues to report")
-		report.Issues = issues
+		issues = model.DedupeIssues(issues)
+		for _, issue := range issues {
+			issue.Submodule = findSubmodule(checkout.Submodules, issue.Location.Path)
+		}
+
+		log.Msg("attributing issues to commits")
+		if err := a.git.BlameIssues(ctx, tmpDir, issues); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			log.Err(err).Msg("unable to attribute issues to commits")
+		}
+
+		log.Msg("applying baselines")
+		model.ApplyBaselines(issues, repo.Baseline, a.readBaselineFile(tmpDir))
+
+		log.Msg("carrying forward triage states")
+		triages, err := a.triage.GetByRepoId(ctx, repo.ID)
+		if err != nil {
+			return storage(err)
+		}
+		model.ApplyTriages(issues, triages, a.app.Clock().Now())
 	}
 
-	report.FinishedAt = time.Now()
-	if err := a.setReportStatus(report, model.StatusSuccess); err != nil {
-		return a.handleFailedTask(report, err)
+	// issues are stored even when none are found, so the issues stored by an
+	// earlier attempt of the scan are replaced
+	log.Msg("storing issues")
+	if err := a.report.AddIssues(ctx, report.ID, issues); err != nil {
+		return storage(err)
+	}
+	report.Issues = issues
+
+	summary.CountIssues(issues)
+
+	log.Msg("evaluating policy")
+	policy, source := model.SelectPolicy(repo, a.app.Config().Policy)
+	report.PolicyResult, err = model.EvaluatePolicy(policy, source, issues)
+	if err != nil {
+		return err
 	}
-	log.Msg("analyzed task completed")
 
 	return nil
 }
 
+// findSubmodule - find the innermost submodule containing the given issue path
+func findSubmodule(submodules []*model.Submodule, issuePath string) *model.Submodule {
+	var found *model.Submodule
+
+	issuePath = strings.TrimPrefix(issuePath, "/")
+	for _, sub := range submodules {
+		if !strings.HasPrefix(issuePath, sub.Path+"/") {
+			continue
+		}
+
+		if found == nil || len(sub.Path) > len(found.Path) {
+			found = sub
+		}
+	}
+
+	return found
+}
+
+// readBaselineFile - read the baseline file committed in the cloned repository, if any
+func (a *Analyzer) readBaselineFile(tmpDir string) *model.Baseline {
+	b, err := os.ReadFile(path.Join(tmpDir, model.BaselineFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Err(err).Msg("unable to read baseline file")
+		}
+		return nil
+	}
+
+	baseline, err := model.ParseBaseline(b)
+	if err != nil {
+		log.Err(err).Msg("unable to parse baseline file")
+		return nil
+	}
+
+	return baseline
+}
+
 // removeTempDir - remove cloned repo directory
 func (a *Analyzer) removeTempDir(tmpDir string) error {
 	if err := os.RemoveAll(tmpDir); err != nil {
@@ -150,35 +415,154 @@ func (a *Analyzer) removeTempDir(tmpDir string) error {
 	return nil
 }
 
-// handleFailedTask - set report status to failed with reason
-func (a *Analyzer) handleFailedTask(report *model.Report, err error) error {
-	log.Err(err)
-	report.FailedReason = err.Error()
-	return a.setReportStatus(report, model.StatusFailed)
+// requeue - put the report of an interrupted task back to the queued status, the returned
+// error makes the queue retry the task
+func (a *Analyzer) requeue(report *model.Report) error {
+	// the context of the task is done already
+	ctx, cancel := context.WithTimeout(context.Background(), requeueTimeout)
+	defer cancel()
+
+	log.Warn().Str("report_id", report.ID).Msg("analyze task interrupted, re-queuing report")
+
+	// an interrupted attempt is not counted
+	report.Attempts--
+	if err := a.setReportStatus(ctx, report, model.StatusEnqueued); err != nil {
+		log.Err(err).Str("report_id", report.ID).Msg("unable to re-queue report")
+	}
+
+	return ErrInterrupted
+}
+
+// Drop - fail the report of a task dropped by the queue and park the task in the
+// dead-letter queue, reports which are finished already are kept as they are
+func (a *Analyzer) Drop(ctx context.Context, reportId string) error {
+	// the context of the task is done already when the last delivery was interrupted
+	ctx, cancel := context.WithTimeout(context.Background(), requeueTimeout)
+	defer cancel()
+
+	report, err := a.report.GetById(ctx, reportId)
+	if err != nil {
+		log.Err(err).Str("report_id", reportId).Msg("unable to fail report of dropped analyze task")
+		return err
+	}
+
+	if report.Status.IsFinished() {
+		return nil
+	}
+
+	log.Error().
+		Str("report_id", report.ID).
+		Int("attempts", report.Attempts).
+		Msg("analyze task dropped by the queue, failing report")
+
+	report.FailedReason = ErrTaskDropped.Error()
+	report.FailureKind = model.FailureInternal
+	if err := a.setReportStatus(ctx, report, model.StatusFailed); err != nil {
+		if errors.Is(err, model.ErrReportStatusChanged) {
+			log.Info().Str("report_id", report.ID).Msg("report was cancelled, not failing it")
+			return nil
+		}
+		return err
+	}
+
+	if err := a.deadLetter(ctx, report.ID); err != nil {
+		log.Err(err).Str("report_id", report.ID).Msg("unable to add report to the dead-letter queue")
+	}
+
+	return nil
 }
 
-// setReportStatus - set report status
-func (a *Analyzer) setReportStatus(report *model.Report, status model.ReportStatus) error {
-	ctx := context.Background()
+// handleFailedTask - classify the error failing a report. Reports failing with a transient
+// error are re-queued with a backoff delay while attempts are left, the others are set to
+// failed with the reason. Reports running out of attempts are parked in the dead-letter queue.
+func (a *Analyzer) handleFailedTask(ctx context.Context, report *model.Report, err error) error {
+	var stage model.ProgressStage
+	if report.Progress != nil {
+		stage = report.Progress.Stage
+	}
+
+	kind := classifyFailure(err, stage)
+	report.FailedReason = err.Error()
+	report.FailureKind = kind
+
+	retry := a.app.Config().Queue.GetRetry()
+	if kind.IsTransient() && report.Attempts < retry.GetMaxAttempts() {
+		delay := retry.Backoff(report.Attempts)
+		log.Warn().Err(err).
+			Str("report_id", report.ID).
+			Str("failure_kind", string(kind)).
+			Int("attempts", report.Attempts).
+			Dur("delay", delay).
+			Msg("analyze task failed with a transient error, retrying")
+
+		if err := a.setReportStatus(ctx, report, model.StatusEnqueued); err != nil {
+			if errors.Is(err, model.ErrReportStatusChanged) {
+				log.Info().Str("report_id", report.ID).Msg("report was cancelled, not retrying")
+				return nil
+			}
+			log.Err(err).Str("report_id", report.ID).Msg("unable to re-queue report")
+		}
 
+		return &RetryError{Err: err, Kind: kind, delay: delay}
+	}
+
+	log.Err(err).
+		Str("report_id", report.ID).
+		Str("failure_kind", string(kind)).
+		Int("attempts", report.Attempts).
+		Msg("analyze task failed")
+
+	if err := a.setReportStatus(ctx, report, model.StatusFailed); err != nil {
+		if errors.Is(err, model.ErrReportStatusChanged) {
+			log.Info().Str("report_id", report.ID).Msg("report was cancelled, not failing it")
+			return nil
+		}
+		return err
+	}
+
+	// permanent failures fail the same way when retried
+	if kind.IsTransient() {
+		if err := a.deadLetter(ctx, report.ID); err != nil {
+			log.Err(err).Str("report_id", report.ID).Msg("unable to add report to the dead-letter queue")
+		}
+	}
+
+	return nil
+}
+
+// setReportStatus - set report status, model.ErrReportStatusChanged is returned when
+// the report was cancelled meanwhile
+func (a *Analyzer) setReportStatus(ctx context.Context, report *model.Report, status model.ReportStatus) error {
 	now := time.Now()
 
 	report.Status = status
 	report.UpdatedAt = now
 
+	if report.Progress == nil {
+		report.Progress = &model.Progress{ReportID: report.ID}
+	}
+
 	switch status {
+	case model.StatusEnqueued:
+		report.Progress.SetStage(model.ProgressQueued, now)
 	case model.StatusInProgress:
+		report.Attempts++
 		report.StartedAt = now
+		report.Progress.SetStage(model.ProgressCloning, now)
 	case model.StatusSuccess:
 		report.FinishedAt = now
+		report.Progress.SetStage(model.ProgressFinished, now)
 	case model.StatusFailed:
 		report.FinishedAt = now
+		report.Progress.SetStage(model.ProgressFailed, now)
 	}
 
-	_, err := a.report.Update(ctx, report)
+	_, err := a.report.UpdateStatus(ctx, report)
 	if err != nil {
 		return err
 	}
 
+	publishProgress(ctx, a.broker, report.Progress)
+
 	return nil
 }
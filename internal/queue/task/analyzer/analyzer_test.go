@@ -3,15 +3,38 @@ package analyzer_test
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/golang/mock/gomock"
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/cmd/database/migrations"
 	"github.com/marktrs/gitsast/internal/model"
 	"github.com/marktrs/gitsast/internal/queue/task/analyzer"
+	"github.com/marktrs/gitsast/internal/queue/task/analyzer/git"
 	mocks "github.com/marktrs/gitsast/testutil/mocks"
 	modelMock "github.com/marktrs/gitsast/testutil/mocks/model"
+	progressMock "github.com/marktrs/gitsast/testutil/mocks/progress"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"github.com/uptrace/bun/migrate"
+	"github.com/vmihailenco/taskq/v3"
+	"github.com/vmihailenco/taskq/v3/memqueue"
 
+	queueMock "github.com/marktrs/gitsast/testutil/mocks/queue"
 	analyzerMock "github.com/marktrs/gitsast/testutil/mocks/queue/analyzer"
 )
 
@@ -23,6 +46,9 @@ type AnalyzerTestSuite struct {
 	report  *modelMock.MockIReportRepo
 	repo    *modelMock.MockIRepositoryRepo
 	rule    *modelMock.MockIRuleRepo
+	triage  *modelMock.MockITriageRepo
+	broker  *progressMock.MockIBroker
+	events  []model.Progress
 	git     *analyzerMock.MockIClient
 	testApp *mocks.TestApp
 
@@ -41,6 +67,8 @@ func (suite *AnalyzerTestSuite) SetupTest() {
 	suite.report = modelMock.NewMockIReportRepo(suite.ctrl)
 	suite.repo = modelMock.NewMockIRepositoryRepo(suite.ctrl)
 	suite.rule = modelMock.NewMockIRuleRepo(suite.ctrl)
+	suite.triage = modelMock.NewMockITriageRepo(suite.ctrl)
+	suite.broker = progressMock.NewMockIBroker(suite.ctrl)
 	suite.git = analyzerMock.NewMockIClient(suite.ctrl)
 	suite.detector = analyzerMock.NewMockDetector(suite.ctrl)
 	suite.scanner = analyzerMock.NewMockScanner(suite.ctrl)
@@ -51,11 +79,22 @@ func (suite *AnalyzerTestSuite) SetupTest() {
 		suite.repo,
 		suite.report,
 		suite.rule,
+		suite.triage,
+		suite.broker,
 		suite.git,
 		suite.detector,
 		suite.scanner,
 	)
 	suite.NoError(err)
+
+	// progress is best effort, record every published event
+	suite.events = nil
+	suite.broker.EXPECT().Publish(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, p *model.Progress) error {
+			suite.events = append(suite.events, *p)
+			return nil
+		}).AnyTimes()
+	suite.report.EXPECT().UpdateProgress(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 }
 
 func (suite *AnalyzerTestSuite) TearDownTest() {
@@ -70,17 +109,882 @@ func (suite *AnalyzerTestSuite) TestAnalyze() {
 		ID: "fake-report-uuid",
 	}, nil)
 	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
-	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any()).Return(nil, nil)
-	suite.report.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil, nil).MaxTimes(2)
-	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&git.Checkout{}, nil)
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Return(nil, nil).MaxTimes(2)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(0)).Return(nil)
 
-	err := suite.analyzer.Analyze("fake-uuid")
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
 	suite.NoError(err)
 }
 
+func (suite *AnalyzerTestSuite) TestAnalyzeRetryWithoutIssues() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-repo-uuid",
+	}, nil)
+	// an earlier attempt of the scan may have stored issues before failing
+	report := &model.Report{
+		ID:       "fake-report-uuid",
+		Status:   model.StatusEnqueued,
+		Attempts: 1,
+	}
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(report, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&git.Checkout{}, nil)
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
+
+	// the retry finds no issues, the issues of the earlier attempt are replaced all the same
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(0)).Return(nil)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.StatusSuccess, report.Status)
+	suite.Equal(2, report.Attempts)
+	suite.Equal(0, report.Summary.TotalIssues)
+}
+
 func (suite *AnalyzerTestSuite) TestAnalyzeError() {
 	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(nil, sql.ErrNoRows)
-	err := suite.analyzer.Analyze("fake-uuid")
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
 	suite.Error(err)
 	suite.EqualError(err, "sql: no rows in result set")
 }
+
+func (suite *AnalyzerTestSuite) TestAnalyzeWithSubmodules() {
+	submodule := &model.Submodule{
+		Path:      "vendor/shared",
+		URL:       "https://github.com/test/shared.git",
+		CommitSHA: "8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e",
+		Depth:     1,
+	}
+
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID:          "fake-repo-uuid",
+		ScanOptions: &model.ScanOptions{Submodules: true, SubmoduleDepth: 2},
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().
+		GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), &git.CloneOptions{SubmoduleDepth: 2}).
+		Return(&git.Checkout{Submodules: []*model.Submodule{submodule}}, nil)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Issue{
+		{RuleID: "G002", Location: model.Location{Path: "/main.go"}},
+		{RuleID: "G002", Location: model.Location{Path: "/vendor/shared/config.yaml"}},
+	}, nil, nil)
+	suite.git.EXPECT().BlameIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(2)).Return(nil)
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			report = r
+			return r, nil
+		}).Times(2)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.StatusSuccess, report.Status)
+	suite.Equal([]*model.Submodule{submodule}, report.Submodules)
+	suite.Nil(report.Issues[0].Submodule)
+	suite.Equal(submodule, report.Issues[1].Submodule)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeWithBaselines() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID:       "fake-baseline-repo-uuid",
+		Baseline: &model.Baseline{Fingerprints: []string{"uploaded"}},
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().
+		GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, tmpDir, _ string, _ *git.CloneOptions) (*git.Checkout, error) {
+			// simulate a baseline file committed in the cloned repository
+			suite.T().Cleanup(func() { os.RemoveAll(filepath.Dir(tmpDir)) })
+			suite.NoError(os.MkdirAll(tmpDir, 0755))
+			suite.NoError(os.WriteFile(
+				filepath.Join(tmpDir, model.BaselineFileName),
+				[]byte(`{"fingerprints": ["committed"]}`),
+				0644,
+			))
+			return &git.Checkout{}, nil
+		})
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Issue{
+		{RuleID: "G001", Fingerprint: "uploaded"},
+		{RuleID: "G001", Fingerprint: "committed"},
+		{RuleID: "G002", Fingerprint: "new"},
+	}, nil, nil)
+	suite.git.EXPECT().BlameIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(3)).Return(nil)
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			report = r
+			return r, nil
+		}).Times(2)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.IssueStatusBaselined, report.Issues[0].Status)
+	suite.Equal(model.IssueStatusBaselined, report.Issues[1].Status)
+	suite.Equal(model.IssueStatusActive, report.Issues[2].Status)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeCarriesForwardTriage() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&git.Checkout{}, nil)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Issue{
+		{RuleID: "G001", Fingerprint: "triaged"},
+		{RuleID: "G002", Fingerprint: "new"},
+	}, nil, nil)
+	suite.git.EXPECT().BlameIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "fake-repo-uuid").Return([]*model.Triage{
+		{
+			RepositoryID: "fake-repo-uuid",
+			Fingerprint:  "triaged",
+			State:        model.TriageFalsePositive,
+			Assignee:     "jane",
+		},
+	}, nil)
+
+	// issues are stored with their carried forward triage state
+	var stored []*model.Issue
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, issues []*model.Issue) error {
+			stored = issues
+			return nil
+		})
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			report = r
+			return r, nil
+		}).Times(2)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.TriageFalsePositive, report.Issues[0].Triage.State)
+	suite.Equal("jane", report.Issues[0].Triage.Assignee)
+	suite.Equal(model.TriageOpen, report.Issues[1].Triage.State)
+	suite.Equal(report.Issues, stored)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeSummary() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&git.Checkout{
+			Paths:     []string{"main.go", "logo.bin"},
+			CommitSHA: "8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e",
+			Ignored:   3,
+		}, nil)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]*model.Issue{
+			{RuleID: "G001", Severity: "HIGH", Fingerprint: "a"},
+			{RuleID: "G001", Severity: "HIGH", Fingerprint: "a"},
+			{RuleID: "G002", Severity: "LOW", Fingerprint: "b"},
+		}, &analyzer.ScanStats{
+			FilesScanned: 1,
+			FilesSkipped: map[model.SkipReason]int{model.SkipBinary: 1},
+			BytesScanned: 42,
+		}, nil)
+	suite.git.EXPECT().BlameIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(2)).Return(nil)
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			report = r
+			return r, nil
+		}).Times(2)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.StatusSuccess, report.Status)
+
+	summary := report.Summary
+	suite.Require().NotNil(summary)
+	suite.Equal("8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e", summary.CommitSHA)
+	suite.Equal(2, summary.TotalIssues)
+	suite.Equal(map[string]int{"HIGH": 1, "LOW": 1}, summary.IssuesBySeverity)
+	suite.Equal(map[string]int{"G001": 1, "G002": 1}, summary.IssuesByRule)
+	suite.Equal(5, summary.FilesDiscovered)
+	suite.Equal(1, summary.FilesScanned)
+	suite.Equal(4, summary.FilesSkipped)
+	suite.Equal(map[model.SkipReason]int{
+		model.SkipIgnoredFileType: 3,
+		model.SkipBinary:          1,
+	}, summary.SkipReasons)
+	suite.Equal(int64(42), summary.BytesScanned)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzePolicy() {
+	testCases := []struct {
+		name   string
+		policy *app.Policy
+		want   *model.PolicyResult
+	}{
+		{
+			name: "global policy",
+			want: &model.PolicyResult{
+				Status: model.PolicyFail,
+				Source: model.PolicySourceGlobal,
+				Violations: []*model.PolicyViolation{
+					{Clause: "no high", Count: 1},
+				},
+			},
+		},
+		{
+			name:   "repository policy",
+			policy: &app.Policy{Clauses: []*app.PolicyClause{{Severity: "HIGH", ExcludePaths: []string{"test/"}}}},
+			want:   &model.PolicyResult{Status: model.PolicyPass, Source: model.PolicySourceRepository},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.testApp.Config().Policy = &app.Policy{Clauses: []*app.PolicyClause{{Name: "no high", Severity: "HIGH"}}}
+
+			suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+				ID:     "fake-repo-uuid",
+				Policy: tc.policy,
+			}, nil)
+			suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+				ID: "fake-report-uuid",
+			}, nil)
+			suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+			suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(&git.Checkout{}, nil)
+			suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return([]*model.Issue{
+					{RuleID: "G001", Severity: "HIGH", Location: model.Location{Path: "/test/key.pem"}},
+				}, nil, nil)
+			suite.git.EXPECT().BlameIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+			suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Any()).Return(nil)
+
+			var report *model.Report
+			suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+					report = r
+					return r, nil
+				}).Times(2)
+
+			err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+			suite.NoError(err)
+			// the scan succeeds whatever the policy result
+			suite.Equal(model.StatusSuccess, report.Status)
+			suite.Equal(tc.want, report.PolicyResult)
+		})
+	}
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeStoreIssuesError() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&git.Checkout{}, nil)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Issue{
+		{RuleID: "G001", Fingerprint: "new"},
+	}, nil, nil)
+	suite.git.EXPECT().BlameIssues(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Any()).Return(sql.ErrConnDone)
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			report = r
+			return r, nil
+		}).Times(2)
+
+	// the database error is retried after the backoff delay
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	var retryErr *analyzer.RetryError
+	suite.ErrorAs(err, &retryErr)
+	suite.ErrorIs(err, sql.ErrConnDone)
+	suite.Equal(30*time.Second, retryErr.Delay())
+	suite.Equal(model.StatusEnqueued, report.Status)
+	suite.Equal(model.ProgressQueued, report.Progress.Stage)
+	suite.Equal(model.FailureDatabase, report.FailureKind)
+	suite.Equal(sql.ErrConnDone.Error(), report.FailedReason)
+	suite.Equal(1, report.Attempts)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeTimeout() {
+	suite.testApp.Config().Scan.Timeout = 10 * time.Millisecond
+
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-timeout-repo-uuid",
+	}, nil)
+	// the last attempt of the report
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID:       "fake-report-uuid",
+		Attempts: 4,
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+
+	queue := queueMock.NewMockHandler(suite.ctrl)
+	queue.EXPECT().AddDeadLetter(gomock.Any()).
+		DoAndReturn(func(msg *taskq.Message) error {
+			suite.Equal(analyzer.TaskName, msg.TaskName)
+			suite.Equal([]interface{}{"fake-report-uuid"}, msg.Args)
+			return nil
+		})
+	suite.testApp.SetQueue(queue)
+
+	suite.git.EXPECT().
+		GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _, _ string, _ *git.CloneOptions) (*git.Checkout, error) {
+			// simulate a hung remote
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, r *model.Report) (*model.Report, error) {
+			// the failed status is saved with a live context
+			suite.NoError(ctx.Err())
+			report = r
+			return r, nil
+		}).Times(2)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.StatusFailed, report.Status)
+	suite.Equal("timeout: scan exceeded the deadline of 10ms", report.FailedReason)
+	suite.Equal(model.FailureCloneTimeout, report.FailureKind)
+	suite.Equal(5, report.Attempts)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeFailureKinds() {
+	tests := []struct {
+		name       string
+		err        error
+		kind       model.FailureKind
+		deadLetter bool
+	}{
+		{
+			name: "authentication required",
+			err:  transport.ErrAuthenticationRequired,
+			kind: model.FailureAuth,
+		},
+		{
+			name: "repository not found",
+			err:  transport.ErrRepositoryNotFound,
+			kind: model.FailureNotFound,
+		},
+		{
+			name: "invalid reference",
+			err:  fmt.Errorf("couldn't find remote ref: %w", plumbing.ErrReferenceNotFound),
+			kind: model.FailureInvalidRef,
+		},
+		{
+			name:       "connection refused",
+			err:        &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			kind:       model.FailureNetwork,
+			deadLetter: true,
+		},
+		{
+			name:       "unavailable git host",
+			err:        plumbing.NewUnexpectedError(&githttp.Err{Response: unavailableResponse()}),
+			kind:       model.FailureNetwork,
+			deadLetter: true,
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("object not found"),
+			kind: model.FailureInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+				ID: "fake-failure-repo-uuid",
+			}, nil)
+			// the report runs out of attempts
+			suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+				ID:       "fake-report-uuid",
+				Attempts: 4,
+			}, nil)
+			suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+			suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, tt.err)
+
+			// only transient failures are parked in the dead-letter queue
+			queue := queueMock.NewMockHandler(suite.ctrl)
+			if tt.deadLetter {
+				queue.EXPECT().AddDeadLetter(gomock.Any()).Return(nil)
+			}
+			suite.testApp.SetQueue(queue)
+
+			var report *model.Report
+			suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+					report = r
+					return r, nil
+				}).Times(2)
+
+			err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+			suite.NoError(err)
+			suite.Equal(model.StatusFailed, report.Status)
+			suite.Equal(tt.kind, report.FailureKind)
+			suite.Equal(tt.err.Error(), report.FailedReason)
+		})
+	}
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeRetryBackoff() {
+	suite.testApp.Config().Queue.Retry = &app.QueueRetry{
+		MaxAttempts: 5,
+		MinBackoff:  time.Second,
+		MaxBackoff:  5 * time.Second,
+	}
+
+	for attempts, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+			ID: "fake-retry-repo-uuid",
+		}, nil)
+		suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+			ID:       "fake-report-uuid",
+			Attempts: attempts,
+		}, nil)
+		suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+		suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, io.ErrUnexpectedEOF)
+
+		var report *model.Report
+		suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+				report = r
+				return r, nil
+			}).Times(2)
+
+		err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+		var retryErr *analyzer.RetryError
+		suite.ErrorAs(err, &retryErr)
+		suite.Equal(model.FailureNetwork, retryErr.Kind)
+		suite.Equal(delay, retryErr.Delay())
+		suite.Equal(model.StatusEnqueued, report.Status)
+		suite.Equal(attempts+1, report.Attempts)
+	}
+}
+
+func unavailableResponse() *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/repo.git/info/refs", nil)
+	return &http.Response{StatusCode: http.StatusServiceUnavailable, Request: req}
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeInterrupted() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-interrupted-repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().
+		GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _, _ string, _ *git.CloneOptions) (*git.Checkout, error) {
+			// the worker shuts down while cloning
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	var report *model.Report
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, r *model.Report) (*model.Report, error) {
+			// the report is re-queued with a live context
+			suite.NoError(ctx.Err())
+			report = r
+			return r, nil
+		}).Times(2)
+
+	err := suite.analyzer.Analyze(ctx, "fake-uuid")
+	suite.ErrorIs(err, analyzer.ErrInterrupted)
+	suite.Equal(time.Second, err.(interface{ Delay() time.Duration }).Delay())
+	suite.Equal(model.StatusEnqueued, report.Status)
+	// the interrupted attempt is not counted
+	suite.Equal(0, report.Attempts)
+	suite.Equal(model.ProgressQueued, report.Progress.Stage)
+	suite.Empty(report.FailedReason)
+}
+
+func (suite *AnalyzerTestSuite) TestDrop() {
+	report := &model.Report{
+		ID:       "fake-report-uuid",
+		Status:   model.StatusEnqueued,
+		Attempts: 1,
+	}
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(report, nil)
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, r *model.Report) (*model.Report, error) {
+			suite.NoError(ctx.Err())
+			return r, nil
+		})
+
+	queue := queueMock.NewMockHandler(suite.ctrl)
+	queue.EXPECT().AddDeadLetter(gomock.Any()).
+		DoAndReturn(func(msg *taskq.Message) error {
+			suite.Equal(analyzer.TaskName, msg.TaskName)
+			suite.Equal([]interface{}{"fake-report-uuid"}, msg.Args)
+			return nil
+		})
+	suite.testApp.SetQueue(queue)
+
+	// the task is dropped once the context of the last delivery is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := suite.analyzer.Drop(ctx, "fake-uuid")
+	suite.NoError(err)
+	suite.Equal(model.StatusFailed, report.Status)
+	suite.Equal(model.FailureInternal, report.FailureKind)
+	suite.Equal(analyzer.ErrTaskDropped.Error(), report.FailedReason)
+	suite.Equal(1, report.Attempts)
+	suite.Equal(model.ProgressFailed, report.Progress.Stage)
+}
+
+func (suite *AnalyzerTestSuite) TestDropFinishedReport() {
+	for _, status := range []model.ReportStatus{
+		model.StatusSuccess,
+		model.StatusFailed,
+		model.StatusCancelled,
+	} {
+		suite.Run(string(status), func() {
+			suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+				ID:     "fake-report-uuid",
+				Status: status,
+			}, nil)
+
+			err := suite.analyzer.Drop(context.Background(), "fake-uuid")
+			suite.NoError(err)
+		})
+	}
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeSkipsCancelledReport() {
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID:     "fake-report-uuid",
+		Status: model.StatusCancelled,
+	}, nil)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeCancelled() {
+	suite.testApp.Config().Scan.CancelPollInterval = 10 * time.Millisecond
+
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-cancelled-repo-uuid",
+	}, nil)
+	gomock.InOrder(
+		suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+			ID:     "fake-report-uuid",
+			Status: model.StatusEnqueued,
+		}, nil),
+		suite.report.EXPECT().GetById(gomock.Any(), "fake-report-uuid").Return(&model.Report{
+			ID:     "fake-report-uuid",
+			Status: model.StatusCancelled,
+		}, nil),
+	)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().
+		GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _, _ string, _ *git.CloneOptions) (*git.Checkout, error) {
+			// simulate a long clone
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	// only the in-progress status is saved, the cancelled status is kept
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			suite.Equal(model.StatusInProgress, r.Status)
+			return r, nil
+		}).Times(1)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeCancelledAfterLastPoll() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-cancelled-repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID:     "fake-report-uuid",
+		Status: model.StatusEnqueued,
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&git.Checkout{}, nil)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil, nil)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(0)).Return(nil)
+
+	// the success status is not written over the cancelled status, nor is the report failed
+	gomock.InOrder(
+		suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+				suite.Equal(model.StatusInProgress, r.Status)
+				return r, nil
+			}),
+		suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+				suite.Equal(model.StatusSuccess, r.Status)
+				return nil, model.ErrReportStatusChanged
+			}),
+	)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+}
+
+func (suite *AnalyzerTestSuite) TestAnalyzeProgress() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ID: "fake-progress-repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Report{
+		ID: "fake-report-uuid",
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+	suite.git.EXPECT().GetPathsFromRemoteURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&git.Checkout{Paths: []string{"a.go", "b.go"}}, nil)
+	suite.scanner.EXPECT().ScanFilesForIssues(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context, _ string, _ []string, _ []*model.Rule, onProgress analyzer.ProgressFunc,
+		) ([]*model.Issue, *analyzer.ScanStats, error) {
+			onProgress(1, 0)
+			onProgress(2, 1)
+			return nil, nil, nil
+		})
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	suite.report.EXPECT().AddIssues(gomock.Any(), "fake-report-uuid", gomock.Len(0)).Return(nil)
+
+	err := suite.analyzer.Analyze(context.Background(), "fake-uuid")
+	suite.NoError(err)
+
+	stages := make([]model.ProgressStage, 0)
+	for _, p := range suite.events {
+		suite.Equal("fake-report-uuid", p.ReportID)
+		stages = append(stages, p.Stage)
+	}
+	suite.Equal([]model.ProgressStage{
+		model.ProgressCloning,
+		model.ProgressScanning,
+		model.ProgressScanning,
+		model.ProgressScanning,
+		model.ProgressFinished,
+	}, stages)
+
+	suite.Equal(2, suite.events[1].FilesDiscovered)
+	suite.Equal(50, suite.events[2].Percent)
+	suite.Equal(1, suite.events[3].IssuesFound)
+	suite.Equal(100, suite.events[4].Percent)
+}
+
+func TestTaskSkipsCancelledReport(t *testing.T) {
+	testApp := mocks.StartTestApp(context.Background())
+	t.Cleanup(testApp.Stop)
+	ctx := context.Background()
+
+	migrator := migrate.NewMigrator(testApp.DB(), migrations.SQLite)
+	assert.NoError(t, migrator.Init(ctx))
+	_, err := migrator.Migrate(ctx)
+	assert.NoError(t, err)
+
+	reports := model.NewReportRepo(testApp.App)
+	report, err := reports.Add(ctx, &model.Report{
+		ID:           "2f0e3a5c-8a52-4f5b-9d0e-7d2d6c1f4b11",
+		RepositoryID: "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Status:       model.StatusEnqueued,
+	})
+	assert.NoError(t, err)
+
+	// the report is cancelled while its task waits in the queue
+	q := memqueue.NewQueue(&taskq.QueueOptions{
+		Name:    "analyzer-cancelled-test",
+		Storage: taskq.NewLocalStorage(),
+	})
+	t.Cleanup(func() { _ = q.Close() })
+	assert.NoError(t, q.Consumer().StopTimeout(time.Second))
+
+	assert.NoError(t, q.Add(analyzer.Task.WithArgs(ctx, report.ID)))
+	assert.NoError(t, reports.Cancel(ctx, report.ID, time.Now()))
+	assert.NoError(t, q.Consumer().Start(testApp.Context()))
+
+	// the task is taken once and deleted without scanning or retrying it
+	assert.Eventually(t, func() bool {
+		return q.Consumer().Stats().Processed == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	stats := q.Consumer().Stats()
+	assert.Equal(t, uint32(1), stats.Processed)
+	assert.Equal(t, uint32(0), stats.Retries)
+	assert.Equal(t, uint32(0), stats.Fails)
+
+	n, err := q.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	got, err := reports.GetById(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusCancelled, got.Status)
+	assert.True(t, got.StartedAt.IsZero())
+	assert.Equal(t, 0, got.Attempts)
+}
+
+// startTaskApp - start an app running analyze tasks against a git host which answers
+// 503 Service Unavailable, interrupt is called instead while a clone is running if set
+func startTaskApp(t *testing.T, maxAttempts int, interrupt *atomic.Pointer[context.CancelFunc]) (context.Context, *app.App, *model.Report) {
+	cfg, err := app.LoadConfigFile(app.FS(), "test", "test")
+	assert.NoError(t, err)
+	cfg.Queue.Retry.MaxAttempts = maxAttempts
+	cfg.Queue.Retry.MinBackoff = 10 * time.Millisecond
+
+	ctx, testApp, err := app.StartWithConfig(context.Background(), cfg)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(testApp.Stop)
+
+	migrator := migrate.NewMigrator(testApp.DB(), migrations.SQLite)
+	assert.NoError(t, migrator.Init(ctx))
+	_, err = migrator.Migrate(ctx)
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cancel := interrupt.Swap(nil); cancel != nil {
+			(*cancel)()
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	repo, err := model.NewRepositoryRepo(testApp).Add(ctx, &model.Repository{
+		ID:        "f0d8368d-85e2-54fb-73c4-2d60374295e3",
+		Name:      "unavailable",
+		RemoteURL: srv.URL + "/repo.git",
+	})
+	assert.NoError(t, err)
+
+	report, err := model.NewReportRepo(testApp).Add(ctx, &model.Report{
+		ID:           "2f0e3a5c-8a52-4f5b-9d0e-7d2d6c1f4b11",
+		RepositoryID: repo.ID,
+		Status:       model.StatusEnqueued,
+	})
+	assert.NoError(t, err)
+
+	// the retry limit of the queue is set on the registered tasks by the consumer
+	assert.NoError(t, testApp.Queue().StartConsumer(ctx, 1))
+	t.Cleanup(func() { _ = testApp.Queue().StopConsumer(time.Second) })
+
+	return ctx, testApp, report
+}
+
+// deliver - run the task of a message the way the queue consumer does, the message is
+// released again when its delay is set and dropped otherwise
+func deliver(ctx context.Context, msg *taskq.Message) error {
+	msg.ReservedCount++
+	msg.Ctx = ctx
+	msg.Err = nil
+	msg.Delay = 0
+	return taskq.Tasks.HandleMessage(msg)
+}
+
+func TestTaskRetriesInterruptedScans(t *testing.T) {
+	var interrupt atomic.Pointer[context.CancelFunc]
+	ctx, testApp, report := startTaskApp(t, 2, &interrupt)
+	reports := model.NewReportRepo(testApp)
+
+	msg := analyzer.Task.WithArgs(ctx, report.ID)
+
+	// the git host is unavailable on the first run
+	err := deliver(ctx, msg)
+	assert.ErrorAs(t, err, new(*analyzer.RetryError))
+	assert.Greater(t, msg.Delay, time.Duration(0))
+
+	// the worker shuts down during the second run, the run is not counted by the report
+	// and the queue keeps the task
+	interrupted, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupt.Store(&cancel)
+
+	err = deliver(interrupted, msg)
+	assert.ErrorIs(t, err, analyzer.ErrInterrupted)
+	assert.Greater(t, msg.Delay, time.Duration(0))
+
+	got, err := reports.GetById(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusEnqueued, got.Status)
+	assert.Equal(t, 1, got.Attempts)
+
+	// the git host is unavailable on the third run, the report runs out of attempts
+	assert.NoError(t, deliver(ctx, msg))
+
+	got, err = reports.GetById(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, got.Status)
+	assert.Equal(t, model.FailureNetwork, got.FailureKind)
+	assert.Equal(t, 2, got.Attempts)
+}
+
+func TestTaskDroppedFailsReport(t *testing.T) {
+	var interrupt atomic.Pointer[context.CancelFunc]
+	ctx, testApp, report := startTaskApp(t, 1, &interrupt)
+
+	// the task was delivered as often as the queue allows
+	msg := analyzer.Task.WithArgs(ctx, report.ID)
+	msg.ReservedCount = analyzer.Task.Options().RetryLimit - 1
+
+	interrupted, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupt.Store(&cancel)
+
+	err := deliver(interrupted, msg)
+	assert.ErrorIs(t, err, analyzer.ErrInterrupted)
+	assert.Equal(t, time.Duration(0), msg.Delay)
+
+	// the queue runs the fallback of the task before dropping it
+	msg.Err = err
+	assert.NoError(t, taskq.Tasks.HandleMessage(msg))
+
+	got, err := model.NewReportRepo(testApp).GetById(ctx, report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, got.Status)
+	assert.Equal(t, model.FailureInternal, got.FailureKind)
+	assert.Equal(t, analyzer.ErrTaskDropped.Error(), got.FailedReason)
+}
@@ -29,15 +29,21 @@ func (d *detector) DetectIssueLocation(fragment Fragment, rule *model.Rule) []*m
 			loc.endLineIndex = matchIndex[1]
 		}
 
+		ruleID := model.GetFormattedRuleId(rule.ID)
+		content := fragment.Raw[loc.startLineIndex:loc.endLineIndex]
+
 		issues = append(issues, &model.Issue{
-			RuleID: model.GetFormattedRuleId(rule.ID),
+			RuleID: ruleID,
 			Location: model.Location{
-				Path: fragment.FilePath,
-				Line: uint64(loc.startLine),
+				Path:    fragment.FilePath,
+				Line:    uint64(loc.startLine),
+				EndLine: uint64(loc.endLine),
 			},
 			Description: rule.Description,
 			Severity:    rule.Severity.String(),
 			Keyword:     rule.Keyword,
+			Fingerprint: model.NewFingerprint(ruleID, fragment.FilePath, content),
+			Snippet:     model.NewSnippet(content),
 		})
 	}
 
@@ -40,6 +40,8 @@ func TestDetectIssueLocation(t *testing.T) {
 					Description: "Public key leak",
 					Severity:    "LOW",
 					Keyword:     `public_key`,
+					Fingerprint: model.NewFingerprint("G001", "tmp.txt", `xibcuvsdf: public_key=sbodufsdfin`),
+					Snippet:     `xibcuvsdf: public_key=sbodufsdfin`,
 				},
 			},
 		},
@@ -66,6 +68,8 @@ func TestDetectIssueLocation(t *testing.T) {
 					Description: "Private key leak",
 					Severity:    "HIGH",
 					Keyword:     `private_key`,
+					Fingerprint: model.NewFingerprint("G002", "tmp.txt", `xibcuvsdf: private_key=sbodufsdfin`),
+					Snippet:     `xibcuvsdf: private_key=sbodufsdfin`,
 				},
 			},
 		},
@@ -77,3 +81,32 @@ func TestDetectIssueLocation(t *testing.T) {
 		assert.EqualValues(t, tc.expectedIssue, issues, "Expected issues does not match")
 	}
 }
+
+func TestDetectIssueFingerprintIgnoresLineShift(t *testing.T) {
+	rule := &model.Rule{
+		ID:       2,
+		Keyword:  `private_key`,
+		Severity: model.High,
+	}
+
+	sc := NewScanner(NewDetector(), 0)
+	before := sc.ScanLineForIssues(Fragment{
+		Raw:      "name: gitsast\nprivate_key: abc\n",
+		FilePath: "config.yaml",
+	}, []*model.Rule{rule})
+	after := sc.ScanLineForIssues(Fragment{
+		Raw:      "name: gitsast\nversion: 2\n\n  private_key:   abc\n",
+		FilePath: "config.yaml",
+	}, []*model.Rule{rule})
+	changed := sc.ScanLineForIssues(Fragment{
+		Raw:      "name: gitsast\nprivate_key: def\n",
+		FilePath: "config.yaml",
+	}, []*model.Rule{rule})
+
+	assert.Len(t, before, 1)
+	assert.Len(t, after, 1)
+	assert.Len(t, changed, 1)
+	assert.NotEqual(t, before[0].Location.Line, after[0].Location.Line)
+	assert.Equal(t, before[0].Fingerprint, after[0].Fingerprint)
+	assert.NotEqual(t, before[0].Fingerprint, changed[0].Fingerprint)
+}
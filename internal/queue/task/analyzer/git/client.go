@@ -1,18 +1,44 @@
 package git
 
 import (
+	"context"
 	"io"
 	"os"
 	"path"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/marktrs/gitsast/internal/model"
 	"github.com/rs/zerolog/log"
 )
 
 type IClient interface {
-	GetPathsFromRemoteURL(tmpDir string, remoteURL string) ([]string, error)
+	GetPathsFromRemoteURL(
+		ctx context.Context,
+		tmpDir string,
+		remoteURL string,
+		opts *CloneOptions,
+	) (*Checkout, error)
+	BlameIssues(ctx context.Context, tmpDir string, issues []*model.Issue) error
+}
+
+// CloneOptions - options for cloning a remote repository
+type CloneOptions struct {
+	// SubmoduleDepth - depth of nested submodules to initialize, 0 disables submodules
+	SubmoduleDepth int
+}
+
+// Checkout - the files of a cloned repository
+type Checkout struct {
+	// Paths are the paths of the files to scan
+	Paths      []string
+	Submodules []*model.Submodule
+	// CommitSHA is the HEAD commit of the cloned repository
+	CommitSHA string
+	// Ignored is the number of files left out by their file type
+	Ignored int
 }
 
 type client struct {
@@ -22,10 +48,16 @@ func NewClient() IClient {
 	return &client{}
 }
 
-// getPathsFromRemoteURL - get all file paths from remote url except ignored file types
-func (c *client) GetPathsFromRemoteURL(tmpDir string, remoteURL string) ([]string, error) {
+// getPathsFromRemoteURL - get all file paths from remote url except ignored file types,
+// including the files of initialized submodules when enabled by the clone options
+func (c *client) GetPathsFromRemoteURL(
+	ctx context.Context,
+	tmpDir string,
+	remoteURL string,
+	opts *CloneOptions,
+) (*Checkout, error) {
 	// local clone
-	r, err := git.PlainClone(tmpDir, false, &git.CloneOptions{URL: remoteURL})
+	r, err := git.PlainCloneContext(ctx, tmpDir, false, &git.CloneOptions{URL: remoteURL})
 	if err != nil {
 		return nil, err
 	}
@@ -35,17 +67,193 @@ func (c *client) GetPathsFromRemoteURL(tmpDir string, remoteURL string) ([]strin
 		return nil, err
 	}
 
+	checkout := &Checkout{CommitSHA: ref.Hash().String()}
+	if err := checkout.addPathsFromHead(r, tmpDir); err != nil {
+		return nil, err
+	}
+
+	if opts == nil || opts.SubmoduleDepth <= 0 {
+		return checkout, nil
+	}
+
+	if err := checkout.addPathsFromSubmodules(ctx, r, tmpDir, "", 1, opts.SubmoduleDepth); err != nil {
+		return nil, err
+	}
+
+	return checkout, nil
+}
+
+// addPathsFromHead - add all file paths from the HEAD tree of a checked out repository
+func (c *Checkout) addPathsFromHead(r *git.Repository, dir string) error {
+	ref, err := r.Head()
+	if err != nil {
+		return err
+	}
+
 	commit, err := r.CommitObject(ref.Hash())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	paths, ignored := excludeIgnorePathsFromTree(object.NewTreeWalker(tree, true, nil), dir)
+	c.Paths = append(c.Paths, paths...)
+	c.Ignored += ignored
+
+	return nil
+}
+
+// addPathsFromSubmodules - initialize and checkout submodules of a repository,
+// then recursively add their file paths until max depth is reached
+func (c *Checkout) addPathsFromSubmodules(
+	ctx context.Context,
+	r *git.Repository,
+	dir string,
+	prefix string,
+	depth int,
+	maxDepth int,
+) error {
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	subs, err := w.Submodules()
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		cfg := sub.Config()
+
+		if err := sub.UpdateContext(ctx, &git.SubmoduleUpdateOptions{Init: true}); err != nil {
+			return err
+		}
+
+		subRepo, err := sub.Repository()
+		if err != nil {
+			return err
+		}
+
+		ref, err := subRepo.Head()
+		if err != nil {
+			return err
+		}
+
+		subDir := path.Join(dir, cfg.Path)
+		if err := c.addPathsFromHead(subRepo, subDir); err != nil {
+			return err
+		}
+
+		c.Submodules = append(c.Submodules, &model.Submodule{
+			Path:      path.Join(prefix, cfg.Path),
+			URL:       cfg.URL,
+			CommitSHA: ref.Hash().String(),
+			Depth:     depth,
+		})
+
+		if depth >= maxDepth {
+			continue
+		}
+
+		err = c.addPathsFromSubmodules(ctx, subRepo, subDir, path.Join(prefix, cfg.Path), depth+1, maxDepth)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BlameIssues - attribute issues found in a cloned repository to the commit
+// that introduced the matched line, files that cannot be blamed are skipped
+func (c *client) BlameIssues(ctx context.Context, tmpDir string, issues []*model.Issue) error {
+	repos := make(map[string]*git.Repository)
+	blames := make(map[string]*git.BlameResult)
+	commits := make(map[plumbing.Hash]*object.Commit)
+
+	for _, issue := range issues {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		repoDir := tmpDir
+		filePath := strings.TrimPrefix(issue.Location.Path, "/")
+
+		// issues inside a submodule are blamed against the submodule history
+		if issue.Submodule != nil {
+			repoDir = path.Join(tmpDir, issue.Submodule.Path)
+			filePath = strings.TrimPrefix(filePath, issue.Submodule.Path+"/")
+		}
+
+		r, ok := repos[repoDir]
+		if !ok {
+			var err error
+			r, err = git.PlainOpen(repoDir)
+			if err != nil {
+				return err
+			}
+			repos[repoDir] = r
+		}
+
+		key := path.Join(repoDir, filePath)
+		result, ok := blames[key]
+		if !ok {
+			var err error
+			result, err = blameFile(r, filePath)
+			if err != nil {
+				log.Err(err).Str("path", filePath).Msg("unable to blame file")
+			}
+			blames[key] = result
+		}
+
+		// issue lines are zero based
+		if result == nil || int(issue.Location.Line) >= len(result.Lines) {
+			continue
+		}
+
+		line := result.Lines[issue.Location.Line]
+		commit, ok := commits[line.Hash]
+		if !ok {
+			var err error
+			commit, err = r.CommitObject(line.Hash)
+			if err != nil {
+				log.Err(err).Str("commit", line.Hash.String()).Msg("unable to get blamed commit")
+			}
+			commits[line.Hash] = commit
+		}
+
+		issue.Commit = &model.Commit{
+			SHA:         line.Hash.String(),
+			AuthorEmail: line.Author,
+			Date:        line.Date,
+		}
+
+		if commit != nil {
+			issue.Commit.AuthorName = commit.Author.Name
+		}
+	}
+
+	return nil
+}
+
+// blameFile - run git blame for a file at HEAD
+func blameFile(r *git.Repository, filePath string) (*git.BlameResult, error) {
+	ref, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := r.CommitObject(ref.Hash())
 	if err != nil {
 		return nil, err
 	}
 
-	return excludeIgnorePathsFromTree(object.NewTreeWalker(tree, true, nil), tmpDir), nil
+	return git.Blame(commit, filePath)
 }
 
 var ignoredFileTypes = []string{
@@ -68,8 +276,10 @@ func isFileTypeIgnored(filename string) bool {
 	return isIgnored
 }
 
-func excludeIgnorePathsFromTree(treeWalker *object.TreeWalker, tmpDir string) []string {
+// excludeIgnorePathsFromTree - list the file paths of a tree and count the files ignored by type
+func excludeIgnorePathsFromTree(treeWalker *object.TreeWalker, tmpDir string) ([]string, int) {
 	filepaths := make([]string, 0)
+	ignored := 0
 	for {
 		name, _, err := treeWalker.Next()
 		if err == io.EOF {
@@ -78,6 +288,7 @@ func excludeIgnorePathsFromTree(treeWalker *object.TreeWalker, tmpDir string) []
 
 		isIgnored := isFileTypeIgnored(name)
 		if isIgnored {
+			ignored++
 			continue
 		}
 
@@ -95,5 +306,5 @@ func excludeIgnorePathsFromTree(treeWalker *object.TreeWalker, tmpDir string) []
 	}
 	defer treeWalker.Close()
 
-	return filepaths
+	return filepaths, ignored
 }
@@ -1,10 +1,15 @@
 package git
 
 import (
+	"context"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/marktrs/gitsast/internal/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,3 +18,155 @@ func TestIsFileTypeIgnored(t *testing.T) {
 		assert.True(t, isFileTypeIgnored(filepath.Join(uuid.New().Domain().String(), ft)))
 	}
 }
+
+func TestGetPathsFromRemoteURLWithSubmodules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is required to prepare test repositories")
+	}
+
+	root := t.TempDir()
+	shared := filepath.Join(root, "shared")
+	service := filepath.Join(root, "service")
+
+	runGit(t, root, "init", "-q", shared)
+	assert.NoError(t, os.WriteFile(filepath.Join(shared, "config.yaml"), []byte("private_key: abc"), 0644))
+	runGit(t, shared, "add", ".")
+	runGit(t, shared, "commit", "-q", "-m", "shared config")
+
+	runGit(t, root, "init", "-q", service)
+	assert.NoError(t, os.WriteFile(filepath.Join(service, "main.go"), []byte("package main"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(service, "logo.png"), []byte{0x89, 'P', 'N', 'G'}, 0644))
+	runGit(t, service, "-c", "protocol.file.allow=always", "submodule", "add", "-q", shared, "vendor/shared")
+	runGit(t, service, "add", ".")
+	runGit(t, service, "commit", "-q", "-m", "add submodule")
+
+	c := NewClient()
+
+	checkout, err := c.GetPathsFromRemoteURL(context.Background(), filepath.Join(root, "clone"), service, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, checkout.Submodules)
+	assert.NotContains(t, checkout.Paths, filepath.Join(root, "clone", "vendor/shared/config.yaml"))
+	assert.Len(t, checkout.CommitSHA, 40)
+	assert.Equal(t, 1, checkout.Ignored)
+
+	checkout, err = c.GetPathsFromRemoteURL(context.Background(),
+		filepath.Join(root, "clone-recursive"), service, &CloneOptions{SubmoduleDepth: 1})
+	assert.NoError(t, err)
+	assert.Contains(t, checkout.Paths, filepath.Join(root, "clone-recursive", "vendor/shared/config.yaml"))
+	assert.Len(t, checkout.Submodules, 1)
+	assert.Equal(t, "vendor/shared", checkout.Submodules[0].Path)
+	assert.Equal(t, 1, checkout.Submodules[0].Depth)
+	assert.Len(t, checkout.Submodules[0].CommitSHA, 40)
+}
+
+func TestGetPathsFromDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "config"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config", "app.yaml"), []byte("private_key: abc"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte{0x89, 'P', 'N', 'G'}, 0644))
+
+	checkout, err := GetPathsFromDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "config", "app.yaml")}, checkout.Paths)
+	assert.Equal(t, 1, checkout.Ignored)
+	assert.Empty(t, checkout.CommitSHA)
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return
+	}
+
+	runGit(t, dir, "init", "-q", ".")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "config")
+
+	checkout, err = GetPathsFromDir(filepath.Join(dir, "config"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "config", "app.yaml")}, checkout.Paths)
+	assert.Len(t, checkout.CommitSHA, 40)
+
+	_, err = GetPathsFromDir(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// commitTime is incremented on every git command so that commits are ordered by date
+var commitTime = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	commitTime = commitTime.Add(time.Minute)
+	date := commitTime.Format(time.RFC3339)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=gitsast", "GIT_AUTHOR_EMAIL=gitsast@example.com",
+		"GIT_COMMITTER_NAME=gitsast", "GIT_COMMITTER_EMAIL=gitsast@example.com",
+		"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+}
+
+func TestBlameIssues(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is required to prepare test repositories")
+	}
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+
+	runGit(t, root, "init", "-q", origin)
+	assert.NoError(t, os.WriteFile(filepath.Join(origin, "config.yaml"), []byte("name: gitsast\n"), 0644))
+	runGit(t, origin, "add", ".")
+	runGit(t, origin, "commit", "-q", "-m", "initial config")
+	assert.NoError(t, os.WriteFile(filepath.Join(origin, "config.yaml"), []byte("name: gitsast\nprivate_key: abc\n"), 0644))
+	runGit(t, origin, "-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com",
+		"commit", "-q", "-a", "--author=Jane Doe <jane@example.com>", "-m", "add key")
+
+	c := NewClient()
+	tmpDir := filepath.Join(root, "clone")
+	_, err := c.GetPathsFromRemoteURL(context.Background(), tmpDir, origin, nil)
+	assert.NoError(t, err)
+
+	issues := []*model.Issue{
+		{RuleID: "G001", Location: model.Location{Path: "/config.yaml", Line: 0}},
+		{RuleID: "G002", Location: model.Location{Path: "/config.yaml", Line: 1}},
+		{RuleID: "G002", Location: model.Location{Path: "/missing.yaml", Line: 0}},
+	}
+	assert.NoError(t, c.BlameIssues(context.Background(), tmpDir, issues))
+
+	assert.Equal(t, "gitsast@example.com", issues[0].Commit.AuthorEmail)
+	assert.Equal(t, "Jane Doe", issues[1].Commit.AuthorName)
+	assert.Equal(t, "jane@example.com", issues[1].Commit.AuthorEmail)
+	assert.Len(t, issues[1].Commit.SHA, 40)
+	assert.NotEqual(t, issues[0].Commit.SHA, issues[1].Commit.SHA)
+	assert.False(t, issues[1].Commit.Date.IsZero())
+	assert.Nil(t, issues[2].Commit)
+}
+
+func TestGetPathsFromRemoteURLCancelled(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is required to prepare test repositories")
+	}
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+
+	runGit(t, root, "init", "-q", origin)
+	assert.NoError(t, os.WriteFile(filepath.Join(origin, "main.go"), []byte("package main"), 0644))
+	runGit(t, origin, "add", ".")
+	runGit(t, origin, "commit", "-q", "-m", "initial commit")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := NewClient()
+	_, err := c.GetPathsFromRemoteURL(ctx, filepath.Join(root, "clone"), origin, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = c.BlameIssues(ctx, filepath.Join(root, "clone"), []*model.Issue{
+		{RuleID: "G001", Location: model.Location{Path: "/main.go"}},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,167 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the header of a hunk, the start line of the new file is captured
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// FileChange - the lines added to a file by a diff
+type FileChange struct {
+	// Path is the path of the file relative to the repository root
+	Path string
+	// Lines are the added lines by their 1-based line number in the new file
+	Lines map[int]string
+}
+
+// Content - the added lines at their line numbers, other lines of the file are left blank
+// so that issues are located on the lines of the new file
+func (f *FileChange) Content() string {
+	numbers := make([]int, 0, len(f.Lines))
+	for n := range f.Lines {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	var b strings.Builder
+	line := 1
+	for _, n := range numbers {
+		b.WriteString(strings.Repeat("\n", n-line))
+		b.WriteString(f.Lines[n])
+		line = n
+	}
+
+	return b.String()
+}
+
+// GetStagedChanges - get the lines added by the staged changes of the repository containing dir,
+// deleted files and ignored file types are left out
+func GetStagedChanges(ctx context.Context, dir string) (string, []*FileChange, error) {
+	root, err := execGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", nil, err
+	}
+	root = strings.TrimSpace(root)
+
+	diff, err := execGit(ctx, root, "-c", "core.quotePath=false",
+		"diff", "--cached", "--unified=0", "--no-color", "--no-ext-diff", "--diff-filter=d")
+	if err != nil {
+		return "", nil, err
+	}
+
+	changes, err := ParseDiff(strings.NewReader(diff))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return root, changes, nil
+}
+
+// ParseDiff - parse the added lines of a unified diff, binary files and deleted files are left out
+func ParseDiff(r io.Reader) ([]*FileChange, error) {
+	changes := make([]*FileChange, 0)
+
+	var (
+		current *FileChange
+		line    int
+		// inHeader is set between the diff line of a file and its first hunk
+		inHeader bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			current = nil
+			inHeader = true
+		case inHeader && strings.HasPrefix(text, "+++ "):
+			name := strings.TrimPrefix(text, "+++ ")
+			if name == "/dev/null" {
+				current = nil
+				continue
+			}
+
+			name, err := unquotePath(name)
+			if err != nil {
+				return nil, err
+			}
+
+			path := strings.TrimPrefix(name, "b/")
+			if isFileTypeIgnored(path) {
+				current = nil
+				continue
+			}
+
+			current = &FileChange{Path: path, Lines: make(map[int]string)}
+			changes = append(changes, current)
+		case strings.HasPrefix(text, "@@"):
+			inHeader = false
+			m := hunkHeader.FindStringSubmatch(text)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header: %q", text)
+			}
+			line, _ = strconv.Atoi(m[1])
+		case current != nil && strings.HasPrefix(text, "+"):
+			current.Lines[line] = strings.TrimPrefix(text, "+")
+			line++
+		case current != nil && strings.HasPrefix(text, " "):
+			// context lines, none with --unified=0
+			line++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// files without added lines, such as pure renames or deletions of lines
+	result := changes[:0]
+	for _, c := range changes {
+		if len(c.Lines) > 0 {
+			result = append(result, c)
+		}
+	}
+
+	return result, nil
+}
+
+// unquotePath - unquote a path of a diff header quoted by git for special characters
+func unquotePath(name string) (string, error) {
+	if !strings.HasPrefix(name, `"`) {
+		return name, nil
+	}
+
+	return strconv.Unquote(name)
+}
+
+// execGit - run a git command in dir and return its output
+func execGit(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git: %s", msg)
+		}
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const stagedDiff = `diff --git a/config/app.yaml b/config/app.yaml
+index 3b18e51..a9c5d3e 100644
+--- a/config/app.yaml
++++ b/config/app.yaml
+@@ -2,0 +3,2 @@ name: app
++private_key: abc
++++ not a header
+@@ -10 +12 @@ port: 80
+-token: old
++token: new
+diff --git "a/docs/caf\303\251.md" "b/docs/caf\303\251.md"
+new file mode 100644
+--- /dev/null
++++ "b/docs/caf\303\251.md"
+@@ -0,0 +1 @@
++public_key=abc
+\ No newline at end of file
+diff --git a/logo.png b/logo.png
+new file mode 100644
+Binary files /dev/null and b/logo.png differ
+diff --git a/removed.txt b/removed.txt
+deleted file mode 100644
+--- a/removed.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-private_key: abc
+`
+
+func TestParseDiff(t *testing.T) {
+	changes, err := ParseDiff(strings.NewReader(stagedDiff))
+	assert.NoError(t, err)
+	assert.Equal(t, []*FileChange{
+		{Path: "config/app.yaml", Lines: map[int]string{3: "private_key: abc", 4: "++ not a header", 12: "token: new"}},
+		{Path: "docs/café.md", Lines: map[int]string{1: "public_key=abc"}},
+	}, changes)
+
+	assert.Equal(t, "\n\nprivate_key: abc\n++ not a header\n\n\n\n\n\n\n\ntoken: new", changes[0].Content())
+
+	_, err = ParseDiff(strings.NewReader("diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ broken @@\n"))
+	assert.ErrorContains(t, err, "invalid hunk header")
+}
+
+func TestGetStagedChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is required to prepare test repositories")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", ".")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.env"), []byte("name=app\nport=80\n"), 0644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "app")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.env"), []byte("name=app\nprivate_key=abc\nport=80\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "unstaged.env"), []byte("private_key=abc\n"), 0644))
+	runGit(t, dir, "add", "app.env")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	root, changes, err := GetStagedChanges(context.Background(), filepath.Join(dir, "sub"))
+	assert.NoError(t, err)
+
+	wantRoot, _ := filepath.EvalSymlinks(dir)
+	gotRoot, _ := filepath.EvalSymlinks(root)
+	assert.Equal(t, wantRoot, gotRoot)
+	assert.Equal(t, []*FileChange{{Path: "app.env", Lines: map[int]string{2: "private_key=abc"}}}, changes)
+
+	_, _, err = GetStagedChanges(context.Background(), t.TempDir())
+	assert.ErrorContains(t, err, "not a git repository")
+}
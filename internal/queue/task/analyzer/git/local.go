@@ -0,0 +1,55 @@
+package git
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// GetPathsFromDir - get all file paths of a local directory except ignored file types and
+// the .git directory, the commit SHA is set when the directory is in a git repository
+func GetPathsFromDir(dir string) (*Checkout, error) {
+	dir = filepath.Clean(dir)
+
+	checkout := &Checkout{Paths: make([]string, 0)}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// symlinks may point outside of the directory
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if isFileTypeIgnored(d.Name()) {
+			checkout.Ignored++
+			return nil
+		}
+
+		checkout.Paths = append(checkout.Paths, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return checkout, nil
+	}
+
+	if ref, err := r.Head(); err == nil {
+		checkout.CommitSHA = ref.Hash().String()
+	}
+
+	return checkout, nil
+}
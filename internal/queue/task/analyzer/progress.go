@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"time"
+
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/progress"
+	"github.com/rs/zerolog/log"
+)
+
+// progressPersistInterval is the minimum interval between two progress updates of a report
+var progressPersistInterval = time.Second
+
+// progressTracker publishes the progress of a report on every change and
+// persists it on the report at most once per progressPersistInterval
+type progressTracker struct {
+	report    model.IReportRepo
+	broker    progress.IBroker
+	progress  *model.Progress
+	lastSaved time.Time
+}
+
+func newProgressTracker(report model.IReportRepo, broker progress.IBroker, p *model.Progress) *progressTracker {
+	return &progressTracker{
+		report:   report,
+		broker:   broker,
+		progress: p,
+	}
+}
+
+// update - publish the current progress, persisting it when forced or the interval elapsed,
+// failures are logged since progress must never fail a scan
+func (t *progressTracker) update(ctx context.Context, force bool) {
+	publishProgress(ctx, t.broker, t.progress)
+
+	now := time.Now()
+	if !force && now.Sub(t.lastSaved) < progressPersistInterval {
+		return
+	}
+
+	t.lastSaved = now
+	if err := t.report.UpdateProgress(ctx, t.progress.ReportID, t.progress); err != nil {
+		log.Err(err).Str("report_id", t.progress.ReportID).Msg("unable to save report progress")
+	}
+}
+
+// publishProgress - publish a progress event, failures are logged only
+func publishProgress(ctx context.Context, broker progress.IBroker, p *model.Progress) {
+	if err := broker.Publish(ctx, p); err != nil {
+		log.Err(err).Str("report_id", p.ReportID).Msg("unable to publish report progress")
+	}
+}
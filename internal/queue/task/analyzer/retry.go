@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+// RetryError is returned by analyze tasks failing with a transient error while attempts
+// are left, the queue retries them after the backoff delay
+type RetryError struct {
+	Err   error
+	Kind  model.FailureKind
+	delay time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return "analyze task failed with a transient error: " + e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Delay implements taskq.Delayer
+func (e *RetryError) Delay() time.Duration {
+	return e.delay
+}
+
+// storageError is an error of the database reading or storing data of a scan
+type storageError struct {
+	err error
+}
+
+func (e *storageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *storageError) Unwrap() error {
+	return e.err
+}
+
+// storage - mark an error as returned by the database, nil stays nil
+func storage(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &storageError{err}
+}
+
+// classifyFailure - classify the error failing a scan, stage is the progress stage
+// the scan failed at
+func classifyFailure(err error, stage model.ProgressStage) model.FailureKind {
+	var stErr *storageError
+	if errors.As(err, &stErr) {
+		// the repository of the report was deleted
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.FailureNotFound
+		}
+		return model.FailureDatabase
+	}
+
+	if errors.Is(err, model.ErrScanTimeout) {
+		if stage == model.ProgressCloning {
+			return model.FailureCloneTimeout
+		}
+		return model.FailureScanTimeout
+	}
+
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod):
+		return model.FailureAuth
+	case errors.Is(err, transport.ErrRepositoryNotFound),
+		errors.Is(err, transport.ErrEmptyRemoteRepository):
+		return model.FailureNotFound
+	case errors.Is(err, plumbing.ErrReferenceNotFound),
+		errors.Is(err, gogit.ErrBranchNotFound),
+		errors.Is(err, gogit.NoMatchingRefSpecError{}):
+		return model.FailureInvalidRef
+	}
+
+	// go-git wraps the errors of its http client without unwrapping them
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		var httpErr *githttp.Err
+		if errors.As(unexpected.Err, &httpErr) {
+			code := httpErr.StatusCode()
+			if code >= http.StatusInternalServerError || code == http.StatusTooManyRequests {
+				return model.FailureNetwork
+			}
+			return model.FailureInternal
+		}
+		return classifyFailure(unexpected.Err, stage)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return model.FailureNetwork
+	}
+
+	return model.FailureInternal
+}
+
+// deadLetter - park the task of a report which ran out of attempts in the dead-letter queue
+func (a *Analyzer) deadLetter(ctx context.Context, reportId string) error {
+	msg := taskq.NewMessage(ctx, reportId)
+	msg.TaskName = TaskName
+
+	return a.app.Queue().AddDeadLetter(msg)
+}
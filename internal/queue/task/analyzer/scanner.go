@@ -2,10 +2,14 @@ package analyzer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fatih/semgroup"
 	"github.com/h2non/filetype"
@@ -30,61 +34,177 @@ type Fragment struct {
 	keywords map[string]bool
 }
 
+// ProgressFunc is called after each scanned file with the number of files scanned
+// and issues found so far, calls are never concurrent
+type ProgressFunc func(filesScanned int, issuesFound int)
+
+// ScanStats counts the files and bytes processed by a scan
+type ScanStats struct {
+	FilesScanned int
+	// FilesSkipped is the number of files skipped by reason
+	FilesSkipped map[model.SkipReason]int
+	BytesScanned int64
+}
+
 // Scanner represents a scanner
 type Scanner interface {
-	ScanFilesForIssues(tmpDir string, paths []string, rules []*model.Rule) ([]*model.Issue, error)
+	ScanFilesForIssues(
+		ctx context.Context,
+		root string,
+		paths []string,
+		rules []*model.Rule,
+		onProgress ProgressFunc,
+	) ([]*model.Issue, *ScanStats, error)
 	ScanLineForIssues(fragment Fragment, rules []*model.Rule) []*model.Issue
 }
 
 type scanner struct {
 	detector Detector
+	// fileTimeout is the time budget for scanning a single file, 0 disables it
+	fileTimeout time.Duration
 }
 
-func NewScanner(detector Detector) Scanner {
+func NewScanner(detector Detector, fileTimeout time.Duration) Scanner {
 	return &scanner{
 		detector,
+		fileTimeout,
 	}
 }
 
-// scanFilesForIssues - scan files for issues
-func (sc *scanner) ScanFilesForIssues(tmpDir string, paths []string, rules []*model.Rule) ([]*model.Issue, error) {
-	issues := make([]*model.Issue, 0)
-	s := semgroup.NewGroup(context.Background(), 4)
+// scanFilesForIssues - scan files under the root directory for issues, the scan stops when
+// the context is done or a file exceeds its time budget
+func (sc *scanner) ScanFilesForIssues(
+	ctx context.Context,
+	root string,
+	paths []string,
+	rules []*model.Rule,
+	onProgress ProgressFunc,
+) ([]*model.Issue, *ScanStats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu         sync.Mutex
+		issues     = make([]*model.Issue, 0)
+		stats      = &ScanStats{FilesSkipped: make(map[model.SkipReason]int)}
+		scanned    int
+		timeoutErr error
+	)
+
+	s := semgroup.NewGroup(ctx, 4)
 	for _, path := range paths {
+		path := path
 		s.Go(func() error {
-			b, err := os.ReadFile(path)
-			if err != nil {
-				return err
+			result, err := sc.scanFile(ctx, root, path, rules)
+			if errors.Is(err, model.ErrScanTimeout) {
+				// stop scanning the remaining files
+				mu.Lock()
+				if timeoutErr == nil {
+					timeoutErr = err
+				}
+				mu.Unlock()
+				cancel()
+				return nil
 			}
-
-			mimetype, err := filetype.Match(b)
 			if err != nil {
 				return err
 			}
 
-			if mimetype.MIME.Type == "application" {
-				return nil // skip binary files
+			mu.Lock()
+			if result.skipped != "" {
+				stats.FilesSkipped[result.skipped]++
+			} else {
+				stats.FilesScanned++
+				stats.BytesScanned += result.size
 			}
-
-			fragment := Fragment{
-				Raw:      string(b),
-				FilePath: path,
-			}
-
-			for _, issue := range sc.ScanLineForIssues(fragment, rules) {
-				issue.Location.Path = strings.ReplaceAll(path, filepath.Join(cloneLocationPrefix, tmpDir), "")
-				issues = append(issues, issue)
+			issues = append(issues, result.issues...)
+			scanned++
+			if onProgress != nil {
+				onProgress(scanned, len(issues))
 			}
+			mu.Unlock()
 
 			return nil
 		})
 	}
 
-	if err := s.Wait(); err != nil {
-		return issues, err
+	err := s.Wait()
+	if timeoutErr != nil {
+		return issues, stats, timeoutErr
+	}
+
+	if err != nil {
+		return issues, stats, err
+	}
+
+	return issues, stats, nil
+}
+
+// fileResult is the outcome of scanning a single file
+type fileResult struct {
+	issues []*model.Issue
+	// skipped is the reason the file was not scanned, empty when scanned
+	skipped model.SkipReason
+	size    int64
+}
+
+// scanFile - scan a single file for issues within the file time budget
+func (sc *scanner) scanFile(ctx context.Context, root string, path string, rules []*model.Rule) (*fileResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mimetype, err := filetype.Match(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if mimetype.MIME.Type == "application" {
+		return &fileResult{skipped: model.SkipBinary}, nil
+	}
+
+	// path relative to the scanned directory, used by fingerprints
+	relPath := path
+	if root != "" && strings.HasPrefix(path, filepath.Clean(root)+"/") {
+		relPath = strings.TrimPrefix(path, filepath.Clean(root))
 	}
 
-	return issues, nil
+	fragment := Fragment{
+		Raw:      string(b),
+		FilePath: relPath,
+	}
+
+	fileCtx := ctx
+	if sc.fileTimeout > 0 {
+		var cancel context.CancelFunc
+		fileCtx, cancel = context.WithTimeout(ctx, sc.fileTimeout)
+		defer cancel()
+	}
+
+	// matching cannot be interrupted, the result of a file over budget is discarded
+	result := make(chan []*model.Issue, 1)
+	go func() {
+		result <- sc.ScanLineForIssues(fragment, rules)
+	}()
+
+	select {
+	case issues := <-result:
+		for _, issue := range issues {
+			issue.Location.Path = relPath
+		}
+		return &fileResult{issues: issues, size: int64(len(b))}, nil
+	case <-fileCtx.Done():
+		if ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: scanning %s exceeded the budget of %s",
+				model.ErrScanTimeout, relPath, sc.fileTimeout)
+		}
+		return nil, ctx.Err()
+	}
 }
 
 // scanLineForIssue - scan a line for issues
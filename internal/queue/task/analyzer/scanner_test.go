@@ -1,8 +1,11 @@
 package analyzer_test
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/marktrs/gitsast/internal/model"
@@ -80,11 +83,11 @@ func TestScanFilesForIssues(t *testing.T) {
 
 		ctrl := gomock.NewController(t)
 		detector := analyzerMock.NewMockDetector(ctrl)
-		scanner := analyzer.NewScanner(detector)
+		scanner := analyzer.NewScanner(detector, 0)
 
 		detector.EXPECT().DetectIssueLocation(gomock.Any(), gomock.Any()).Return(tc.expectedIssue)
 
-		issues, err := scanner.ScanFilesForIssues(tc.path, []string{tc.path}, tc.rules)
+		issues, _, err := scanner.ScanFilesForIssues(context.Background(), tc.path, []string{tc.path}, tc.rules, nil)
 		if tc.wantError != nil {
 			assert.EqualError(t, err, tc.wantError.Error())
 			continue
@@ -96,6 +99,108 @@ func TestScanFilesForIssues(t *testing.T) {
 	}
 }
 
+func TestScanFilesForIssuesConcurrently(t *testing.T) {
+	paths := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		path := fmt.Sprintf("concurrent-%d.key", i)
+		prepareTestFiles(t, path, `private_key=sbodufsdfin`)
+		defer cleanupTestFiles(t, path)
+		paths = append(paths, path)
+	}
+
+	ctrl := gomock.NewController(t)
+	detector := analyzerMock.NewMockDetector(ctrl)
+	scanner := analyzer.NewScanner(detector, time.Second)
+
+	detector.EXPECT().DetectIssueLocation(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(fragment analyzer.Fragment, _ *model.Rule) []*model.Issue {
+			return []*model.Issue{{RuleID: "G002", Location: model.Location{Path: fragment.FilePath}}}
+		}).Times(len(paths))
+
+	progress := make([]int, 0)
+	issues, stats, err := scanner.ScanFilesForIssues(context.Background(), "", paths, []*model.Rule{
+		{ID: 2, Keyword: `private_key`},
+	}, func(filesScanned, issuesFound int) {
+		assert.Equal(t, filesScanned, issuesFound)
+		progress = append(progress, filesScanned)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, issues, len(paths))
+	assert.Equal(t, len(paths), stats.FilesScanned)
+	assert.Equal(t, int64(len(paths)*len(`private_key=sbodufsdfin`)), stats.BytesScanned)
+
+	// progress is reported once per file in order
+	for i, filesScanned := range progress {
+		assert.Equal(t, i+1, filesScanned)
+	}
+	assert.Len(t, progress, len(paths))
+
+	// every file is scanned exactly once
+	scanned := make(map[string]bool)
+	for _, issue := range issues {
+		scanned[issue.Location.Path] = true
+	}
+	assert.Len(t, scanned, len(paths))
+}
+
+func TestScanFilesForIssuesSkipBinary(t *testing.T) {
+	prepareTestFiles(t, "plain.key", `private_key=sbodufsdfin`)
+	defer cleanupTestFiles(t, "plain.key")
+	prepareTestFiles(t, "archive.key", "\x1f\x8b\x08private_key=sbodufsdfin")
+	defer cleanupTestFiles(t, "archive.key")
+
+	ctrl := gomock.NewController(t)
+	detector := analyzerMock.NewMockDetector(ctrl)
+	scanner := analyzer.NewScanner(detector, time.Second)
+
+	detector.EXPECT().DetectIssueLocation(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	_, stats, err := scanner.ScanFilesForIssues(context.Background(), "", []string{"plain.key", "archive.key"},
+		[]*model.Rule{{ID: 2, Keyword: `private_key`}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.FilesScanned)
+	assert.Equal(t, map[model.SkipReason]int{model.SkipBinary: 1}, stats.FilesSkipped)
+	assert.Equal(t, int64(len(`private_key=sbodufsdfin`)), stats.BytesScanned)
+}
+
+func TestScanFilesForIssuesFileTimeout(t *testing.T) {
+	prepareTestFiles(t, "slow.key", `private_key=sbodufsdfin`)
+	defer cleanupTestFiles(t, "slow.key")
+
+	ctrl := gomock.NewController(t)
+	detector := analyzerMock.NewMockDetector(ctrl)
+	scanner := analyzer.NewScanner(detector, 10*time.Millisecond)
+
+	detector.EXPECT().DetectIssueLocation(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(analyzer.Fragment, *model.Rule) []*model.Issue {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		})
+
+	_, _, err := scanner.ScanFilesForIssues(context.Background(), "", []string{"slow.key"}, []*model.Rule{
+		{ID: 2, Keyword: `private_key`},
+	}, nil)
+	assert.ErrorIs(t, err, model.ErrScanTimeout)
+	assert.EqualError(t, err, "timeout: scanning slow.key exceeded the budget of 10ms")
+}
+
+func TestScanFilesForIssuesCancelled(t *testing.T) {
+	prepareTestFiles(t, "cancelled.key", `private_key=sbodufsdfin`)
+	defer cleanupTestFiles(t, "cancelled.key")
+
+	ctrl := gomock.NewController(t)
+	detector := analyzerMock.NewMockDetector(ctrl)
+	scanner := analyzer.NewScanner(detector, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := scanner.ScanFilesForIssues(ctx, "", []string{"cancelled.key"}, []*model.Rule{
+		{ID: 2, Keyword: `private_key`},
+	}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func prepareTestFiles(t *testing.T, path, content string) {
 	_, err := os.Create(path)
 	assert.NoError(t, err)
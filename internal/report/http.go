@@ -0,0 +1,234 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/marktrs/gitsast/app/middleware"
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bunrouter"
+)
+
+// HTTPHandler variable that does static check to make sure that httpHandler struct implements HTTPHandler interface.
+var _ HTTPHandler = (*httpHandler)(nil)
+
+var (
+	ErrInvalidParam = middleware.BadRequest(errors.New("error invalid parameter"))
+)
+
+// keepAliveInterval is the interval of comments sent on idle event streams
+var keepAliveInterval = 15 * time.Second
+
+// HTTPHandler defines methods for http handler of report domain
+// such as parse request, query and create response
+type HTTPHandler interface {
+	GetById(http.ResponseWriter, bunrouter.Request) error
+	GetSummary(http.ResponseWriter, bunrouter.Request) error
+	ListIssues(http.ResponseWriter, bunrouter.Request) error
+	Events(http.ResponseWriter, bunrouter.Request) error
+}
+
+type httpHandler struct {
+	service IService
+}
+
+func NewHTTPHandler(s IService) HTTPHandler {
+	return &httpHandler{
+		service: s,
+	}
+}
+
+// GetById implements HTTPHandler.GetById interface.
+func (h *httpHandler) GetById(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to get report by ID")
+		return ErrInvalidParam
+	}
+
+	e, err := exporter(req)
+	if err != nil {
+		return err
+	}
+
+	// the report is exported with its issues when a format is requested, else it is
+	// returned with its summary and its issues are listed by the issues endpoint
+	if e.Format() != export.FormatJSON || req.URL.Query().Has("format") {
+		f, err := model.DecodeReportFilter(req)
+		if err != nil {
+			return err
+		}
+
+		// render into a buffer so errors are still reported with a proper status
+		var buf bytes.Buffer
+		if err := h.service.Export(ctx, &buf, id, f, e); err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", e.ContentType())
+		_, err = buf.WriteTo(w)
+		return err
+	}
+
+	response, err := h.service.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, &response)
+}
+
+// GetSummary implements HTTPHandler.GetSummary interface.
+func (h *httpHandler) GetSummary(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to get report summary by ID")
+		return ErrInvalidParam
+	}
+
+	response, err := h.service.GetSummary(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, &response)
+}
+
+// ListIssues implements HTTPHandler.ListIssues interface.
+func (h *httpHandler) ListIssues(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to list issues by report ID")
+		return ErrInvalidParam
+	}
+
+	f, err := model.DecodeIssueFilter(req)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.service.ListIssues(ctx, id, f)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, &response)
+}
+
+// Events implements HTTPHandler.Events interface.
+// It streams the progress of a scan as Server-Sent Events until the scan is done.
+func (h *httpHandler) Events(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to stream events by report ID")
+		return ErrInvalidParam
+	}
+
+	report, events, err := h.service.Events(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// the stream outlives the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// errors after the stream started cannot be sent to the client anymore
+	current := report.CurrentProgress()
+	if err := writeEvent(w, rc, current); err != nil {
+		log.Err(err).Str("report_id", id).Msg("unable to write progress event")
+		return nil
+	}
+
+	if current.Stage.IsDone() {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			if err := rc.Flush(); err != nil {
+				return nil
+			}
+		case p, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := writeEvent(w, rc, p); err != nil {
+				log.Err(err).Str("report_id", id).Msg("unable to write progress event")
+				return nil
+			}
+
+			if p.Stage.IsDone() {
+				return nil
+			}
+		}
+	}
+}
+
+// exporter - select the exporter of a report by the format query param,
+// or else by the Accept header, defaults to JSON
+func exporter(req bunrouter.Request) (export.Exporter, error) {
+	if format := req.URL.Query().Get("format"); format != "" {
+		e, err := export.Get(format)
+		if err != nil {
+			return nil, model.InvalidQueryParam("format", err)
+		}
+		return e, nil
+	}
+
+	if e, ok := export.Negotiate(req.Header.Get("Accept")); ok {
+		return e, nil
+	}
+
+	return export.Get(export.FormatJSON)
+}
+
+// writeEvent - write a progress event to the stream and flush it to the client
+func writeEvent(w io.Writer, rc *http.ResponseController, p *model.Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
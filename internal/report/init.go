@@ -0,0 +1,34 @@
+package report
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/app/middleware"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/progress"
+	"github.com/uptrace/bunrouter"
+)
+
+func init() {
+	middleware.RegisterErrorStatus(http.StatusBadRequest, model.ErrInvalidQueryParam)
+
+	app.OnStart("report.initRoutes", func(ctx context.Context, app *app.App) error {
+		rp := model.NewReportRepo(app)
+		ru := model.NewRuleRepo(app)
+		tr := model.NewTriageRepo(app)
+		broker := progress.NewBroker(app)
+		s := NewService(app, rp, ru, tr, broker)
+		h := NewHTTPHandler(s)
+
+		app.APIRouter().WithGroup("/reports", func(g *bunrouter.Group) {
+			g.GET("/:id", h.GetById)
+			g.GET("/:id/summary", h.GetSummary)
+			g.GET("/:id/issues", h.ListIssues)
+			g.GET("/:id/events", h.Events)
+		})
+
+		return nil
+	})
+}
@@ -0,0 +1,191 @@
+package report
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/progress"
+)
+
+// IService variable that does static check to make sure that 'service' struct implements 'IService' interface.
+var _ IService = (*service)(nil)
+
+// IService defines methods for business logic of report domain
+// such as get a report by its ID, list its issues, streaming the progress of a scan
+type IService interface {
+	GetById(ctx context.Context, id string) (*model.Report, error)
+	GetSummary(ctx context.Context, id string) (*SummaryResponse, error)
+	Export(ctx context.Context, w io.Writer, id string, f *model.ReportFilter, e export.Exporter) error
+	ListIssues(ctx context.Context, id string, f *model.IssueFilter) (*ListIssuesResponse, error)
+	Events(ctx context.Context, id string) (*model.Report, <-chan *model.Progress, error)
+}
+
+type service struct {
+	app *app.App
+
+	report model.IReportRepo
+	rule   model.IRuleRepo
+	triage model.ITriageRepo
+	broker progress.IBroker
+}
+
+func NewService(
+	app *app.App,
+	rp model.IReportRepo,
+	ru model.IRuleRepo,
+	tr model.ITriageRepo,
+	broker progress.IBroker,
+) IService {
+	return &service{
+		app:    app,
+		report: rp,
+		rule:   ru,
+		triage: tr,
+		broker: broker,
+	}
+}
+
+// GetById implements IService.GetById interface.
+// It reads the report with its stored summary, the issues are not loaded.
+func (s *service) GetById(ctx context.Context, id string) (*model.Report, error) {
+	return s.report.GetById(ctx, id)
+}
+
+type SummaryResponse struct {
+	ReportID     string             `json:"report_id"`
+	RepositoryID string             `json:"repository_id"`
+	Status       model.ReportStatus `json:"status"`
+	// Summary is empty until the worker picks up the report
+	Summary      *model.Summary      `json:"summary"`
+	PolicyResult *model.PolicyResult `json:"policy_result,omitempty"`
+}
+
+// GetSummary implements IService.GetSummary interface.
+// It reads the summary stored with the report, the issues are not loaded.
+func (s *service) GetSummary(ctx context.Context, id string) (*SummaryResponse, error) {
+	report, err := s.report.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SummaryResponse{
+		ReportID:     report.ID,
+		RepositoryID: report.RepositoryID,
+		Status:       report.Status,
+		Summary:      report.Summary,
+		PolicyResult: report.PolicyResult,
+	}, nil
+}
+
+// Export implements IService.Export interface.
+// It writes the report with its issues matching the filter using the given exporter.
+func (s *service) Export(
+	ctx context.Context,
+	w io.Writer,
+	id string,
+	f *model.ReportFilter,
+	e export.Exporter,
+) error {
+	report, err := s.report.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	report.Issues, err = s.report.GetIssues(ctx, report.ID)
+	if err != nil {
+		return err
+	}
+
+	// carry forward the current triage state of findings
+	triages, err := s.triage.GetByRepoId(ctx, report.RepositoryID)
+	if err != nil {
+		return err
+	}
+
+	rules, err := s.rule.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	model.ApplyTriages(report.Issues, triages, s.app.Clock().Now())
+	report.Issues = f.FilterIssues(report.Issues)
+
+	return e.Export(w, report, rules)
+}
+
+type ListIssuesResponse struct {
+	Findings []*model.Finding `json:"findings"`
+	// NextCursor is the cursor of the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListIssues implements IService.ListIssues interface.
+// Issues are read by pages of the query until the page of the response is full,
+// the author and triage filters are matched once the issues are read.
+func (s *service) ListIssues(ctx context.Context, id string, f *model.IssueFilter) (*ListIssuesResponse, error) {
+	report, err := s.report.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// carry forward the current triage state of findings
+	triages, err := s.triage.GetByRepoId(ctx, report.RepositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch one more issue than requested to know whether there is a next page
+	page := *f
+	page.Limit = f.Limit + 1
+
+	matched := make([]*model.Issue, 0, page.Limit)
+	for {
+		issues, err := s.report.ListIssues(ctx, report.ID, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		model.ApplyTriages(issues, triages, s.app.Clock().Now())
+		for _, issue := range issues {
+			if f.MatchIssue(issue) {
+				matched = append(matched, issue)
+			}
+		}
+
+		if len(matched) > f.Limit || len(issues) < page.Limit {
+			break
+		}
+		page.Cursor = issues[len(issues)-1].ID
+	}
+
+	response := &ListIssuesResponse{}
+	if len(matched) > f.Limit {
+		matched = matched[:f.Limit]
+		response.NextCursor = strconv.FormatUint(matched[len(matched)-1].ID, 10)
+	}
+	response.Findings = model.NewFindings(matched)
+
+	return response, nil
+}
+
+// Events implements IService.Events interface.
+// It returns the report with its current progress and the progress events
+// published afterward, until the context is done.
+func (s *service) Events(ctx context.Context, id string) (*model.Report, <-chan *model.Progress, error) {
+	// subscribe before reading the report so no event is missed in between
+	events, err := s.broker.Subscribe(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := s.report.GetById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return report, events, nil
+}
@@ -0,0 +1,421 @@
+package report_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marktrs/gitsast/internal/export"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/report"
+	"github.com/stretchr/testify/suite"
+	"github.com/uptrace/bunrouter"
+
+	mocks "github.com/marktrs/gitsast/testutil/mocks"
+	modelMock "github.com/marktrs/gitsast/testutil/mocks/model"
+	progressMock "github.com/marktrs/gitsast/testutil/mocks/progress"
+)
+
+type ServiceTestSuite struct {
+	suite.Suite
+
+	ctrl    *gomock.Controller
+	report  *modelMock.MockIReportRepo
+	rule    *modelMock.MockIRuleRepo
+	triage  *modelMock.MockITriageRepo
+	broker  *progressMock.MockIBroker
+	testApp *mocks.TestApp
+
+	service report.IService
+	router  *bunrouter.Router
+}
+
+func TestServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ServiceTestSuite))
+}
+
+func (suite *ServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.report = modelMock.NewMockIReportRepo(suite.ctrl)
+	suite.rule = modelMock.NewMockIRuleRepo(suite.ctrl)
+	suite.triage = modelMock.NewMockITriageRepo(suite.ctrl)
+	suite.broker = progressMock.NewMockIBroker(suite.ctrl)
+	suite.testApp = mocks.StartTestApp(context.Background())
+
+	suite.service = report.NewService(suite.testApp.App, suite.report, suite.rule, suite.triage, suite.broker)
+
+	h := report.NewHTTPHandler(suite.service)
+	suite.router = bunrouter.New()
+	suite.router.GET("/reports/:id", h.GetById)
+	suite.router.GET("/reports/:id/summary", h.GetSummary)
+	suite.router.GET("/reports/:id/events", h.Events)
+}
+
+func (suite *ServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func (suite *ServiceTestSuite) TestGetById() {
+	summary := model.NewSummary()
+	summary.CountIssues([]*model.Issue{{RuleID: "G001", Severity: "HIGH"}, {RuleID: "G002", Severity: "LOW"}})
+
+	// the stored summary is served, issues are not loaded
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+		Status:       model.StatusSuccess,
+		Summary:      summary,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid", nil))
+	suite.Equal(http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Equal("fake-uuid", response["id"])
+	suite.NotContains(response, "findings")
+
+	s := response["summary"].(map[string]interface{})
+	suite.Equal(float64(2), s["total_issues"])
+	suite.Equal(map[string]interface{}{"HIGH": float64(1), "LOW": float64(1)}, s["issues_by_severity"])
+}
+
+func (suite *ServiceTestSuite) TestGetByIdNotFound() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	_, err := suite.service.GetById(context.Background(), "fake-uuid")
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *ServiceTestSuite) TestGetJSONExport() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+		Status:       model.StatusSuccess,
+	}, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "fake-uuid").Return([]*model.Issue{
+		{RuleID: "G001", Fingerprint: "confirmed", Location: model.Location{Path: "pub.key"}},
+		{RuleID: "G002", Fingerprint: "untriaged", Location: model.Location{Path: "priv.key"}},
+	}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "repo-uuid").Return([]*model.Triage{
+		{RepositoryID: "repo-uuid", Fingerprint: "confirmed", State: model.TriageConfirmed},
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+
+	// the report is exported with all its findings when the json format is requested
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid?format=json&triage=confirmed", nil))
+	suite.Equal(http.StatusOK, w.Code)
+
+	var response model.ReportResponse
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Equal("fake-uuid", response.ID)
+	if suite.Len(response.Findings, 1) {
+		suite.Equal("pub.key", response.Findings[0].Location.Path)
+		suite.Equal(model.TriageConfirmed, response.Findings[0].Triage.State)
+	}
+}
+
+func (suite *ServiceTestSuite) TestGetSummary() {
+	summary := model.NewSummary()
+	summary.CommitSHA = "8f1d5a3c6b1e2f0a9d7c4b3a2e1f0d9c8b7a6f5e"
+	summary.CountIssues([]*model.Issue{{RuleID: "G001", Severity: "HIGH"}})
+	summary.Skip(model.SkipBinary, 2)
+
+	// issues are not loaded
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+		Status:       model.StatusSuccess,
+		Summary:      summary,
+		PolicyResult: &model.PolicyResult{Status: model.PolicyFail, Source: model.PolicySourceGlobal},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid/summary", nil))
+	suite.Equal(http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Equal("fake-uuid", response["report_id"])
+	suite.Equal("success", response["status"])
+
+	s := response["summary"].(map[string]interface{})
+	suite.Equal(summary.CommitSHA, s["commit_sha"])
+	suite.Equal(float64(1), s["total_issues"])
+	suite.Equal(map[string]interface{}{"HIGH": float64(1)}, s["issues_by_severity"])
+	suite.Equal(map[string]interface{}{"binary": float64(2)}, s["skip_reasons"])
+	suite.Equal(float64(2), s["files_skipped"])
+	suite.Equal(map[string]interface{}{"status": "fail", "source": "global"}, response["policy_result"])
+}
+
+func (suite *ServiceTestSuite) TestGetSummaryNotFound() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	_, err := suite.service.GetSummary(context.Background(), "fake-uuid")
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *ServiceTestSuite) TestGetSARIF() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+		Status:       model.StatusSuccess,
+	}, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "fake-uuid").Return([]*model.Issue{
+		{RuleID: "G001", Fingerprint: "accepted", Severity: "LOW", Location: model.Location{Path: "/pub.key"}},
+		{RuleID: "G002", Fingerprint: "untriaged", Severity: "HIGH", Location: model.Location{Path: "/priv.key"}},
+	}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "repo-uuid").Return([]*model.Triage{
+		{RepositoryID: "repo-uuid", Fingerprint: "accepted", State: model.TriageAcceptedRisk},
+	}, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return([]*model.Rule{
+		{ID: 1, Name: "Public key leak", Severity: model.Low},
+		{ID: 2, Name: "Private key leak", Severity: model.High},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid?format=sarif", nil))
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("application/sarif+json", w.Header().Get("Content-Type"))
+
+	var log export.SARIF
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &log))
+	suite.Equal("2.1.0", log.Version)
+	suite.Len(log.Runs[0].Tool.Driver.Rules, 2)
+	suite.Len(log.Runs[0].Results, 2)
+	suite.Equal("triaged as accepted risk", log.Runs[0].Results[0].Suppressions[0].Justification)
+	suite.Empty(log.Runs[0].Results[1].Suppressions)
+}
+
+func (suite *ServiceTestSuite) TestGetGitLabReport() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+		Status:       model.StatusSuccess,
+	}, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "fake-uuid").Return([]*model.Issue{
+		{RuleID: "G001", Fingerprint: "baselined", Severity: "LOW", Status: model.IssueStatusBaselined},
+		{RuleID: "G002", Fingerprint: "active", Severity: "HIGH", Status: model.IssueStatusActive},
+	}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "repo-uuid").Return(nil, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(
+		http.MethodGet, "/reports/fake-uuid?format=gitlab-secret-detection&status=active", nil))
+
+	suite.Equal(http.StatusOK, w.Code)
+
+	var gl export.GitLabReport
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &gl))
+	suite.Equal(export.GitLabSecretDetection, gl.Scan.Type)
+	suite.Len(gl.Vulnerabilities, 1)
+	suite.Equal("active", gl.Vulnerabilities[0].ID)
+	suite.Equal("High", gl.Vulnerabilities[0].Severity)
+}
+
+func (suite *ServiceTestSuite) TestGetByIdInvalidFormat() {
+	w := httptest.NewRecorder()
+	err := suite.router.ServeHTTPError(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid?format=pdf", nil))
+	suite.ErrorIs(err, export.ErrUnsupportedFormat)
+	suite.ErrorContains(err, "invalid query param value: format")
+}
+
+func (suite *ServiceTestSuite) TestGetByIdAcceptHeader() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+	}, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "fake-uuid").Return([]*model.Issue{
+		{RuleID: "G001", Severity: "LOW", Location: model.Location{Path: "/pub.key"}, Snippet: "public_key=<abc>"},
+	}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "repo-uuid").Return(nil, nil)
+	suite.rule.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/reports/fake-uuid", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	suite.Contains(w.Body.String(), "public_key=&lt;abc&gt;")
+}
+
+func (suite *ServiceTestSuite) TestGetByIdExportNotFound() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	err := suite.router.ServeHTTPError(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid?format=csv", nil))
+	suite.ErrorIs(err, sql.ErrNoRows)
+	suite.Empty(w.Body.String())
+}
+
+func (suite *ServiceTestSuite) TestListIssues() {
+	f := &model.IssueFilter{Severity: "HIGH", Limit: 2, Cursor: 10}
+
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+	}, nil)
+	suite.report.EXPECT().
+		ListIssues(gomock.Any(), "fake-uuid", &model.IssueFilter{Severity: "HIGH", Limit: 3, Cursor: 10}).
+		Return([]*model.Issue{
+			{ID: 11, RuleID: "G002", Fingerprint: "confirmed", Severity: "HIGH"},
+			{ID: 12, RuleID: "G002", Fingerprint: "untriaged", Severity: "HIGH"},
+			{ID: 15, RuleID: "G002", Fingerprint: "next-page", Severity: "HIGH"},
+		}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "repo-uuid").Return([]*model.Triage{
+		{RepositoryID: "repo-uuid", Fingerprint: "confirmed", State: model.TriageConfirmed},
+	}, nil)
+
+	response, err := suite.service.ListIssues(context.Background(), "fake-uuid", f)
+	suite.NoError(err)
+	suite.Len(response.Findings, 2)
+	suite.Equal("12", response.NextCursor)
+	suite.Equal(model.TriageConfirmed, response.Findings[0].Triage.State)
+	suite.Equal(model.TriageOpen, response.Findings[1].Triage.State)
+}
+
+func (suite *ServiceTestSuite) TestListIssuesLastPage() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{ID: "fake-uuid"}, nil)
+	suite.report.EXPECT().ListIssues(gomock.Any(), "fake-uuid", gomock.Any()).Return([]*model.Issue{
+		{ID: 1, RuleID: "G001"},
+	}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	response, err := suite.service.ListIssues(context.Background(), "fake-uuid", &model.IssueFilter{Limit: 2})
+	suite.NoError(err)
+	suite.Len(response.Findings, 1)
+	suite.Empty(response.NextCursor)
+}
+
+func (suite *ServiceTestSuite) TestListIssuesFilterByTriage() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:           "fake-uuid",
+		RepositoryID: "repo-uuid",
+	}, nil)
+	// pages of the query are read until the page of the response is full
+	gomock.InOrder(
+		suite.report.EXPECT().
+			ListIssues(gomock.Any(), "fake-uuid", &model.IssueFilter{Triage: model.TriageConfirmed, Limit: 2}).
+			Return([]*model.Issue{
+				{ID: 1, RuleID: "G001", Fingerprint: "confirmed"},
+				{ID: 2, RuleID: "G002", Fingerprint: "untriaged"},
+			}, nil),
+		suite.report.EXPECT().
+			ListIssues(gomock.Any(), "fake-uuid", &model.IssueFilter{Triage: model.TriageConfirmed, Limit: 2, Cursor: 2}).
+			Return([]*model.Issue{
+				{ID: 3, RuleID: "G002", Fingerprint: "untriaged"},
+			}, nil),
+	)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "repo-uuid").Return([]*model.Triage{
+		{RepositoryID: "repo-uuid", Fingerprint: "confirmed", State: model.TriageConfirmed},
+	}, nil)
+
+	response, err := suite.service.ListIssues(
+		context.Background(), "fake-uuid", &model.IssueFilter{Triage: model.TriageConfirmed, Limit: 1})
+	suite.NoError(err)
+	if suite.Len(response.Findings, 1) {
+		suite.Equal("G001", response.Findings[0].RuleID)
+		suite.Equal(model.TriageConfirmed, response.Findings[0].Triage.State)
+	}
+	suite.Empty(response.NextCursor)
+}
+
+func (suite *ServiceTestSuite) TestListIssuesFilterByAuthor() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{ID: "fake-uuid"}, nil)
+	suite.report.EXPECT().ListIssues(gomock.Any(), "fake-uuid", gomock.Any()).Return([]*model.Issue{
+		{ID: 1, RuleID: "G001", Commit: &model.Commit{AuthorName: "Jane Doe", AuthorEmail: "jane@example.com"}},
+		{ID: 2, RuleID: "G002", Commit: &model.Commit{AuthorName: "John Doe", AuthorEmail: "john@example.com"}},
+		{ID: 3, RuleID: "G002"},
+		{ID: 4, RuleID: "G003", Commit: &model.Commit{AuthorName: "Jane Doe", AuthorEmail: "jane@example.com"}},
+	}, nil)
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	response, err := suite.service.ListIssues(
+		context.Background(), "fake-uuid", &model.IssueFilter{Author: "JANE@example.com", Limit: 1})
+	suite.NoError(err)
+	if suite.Len(response.Findings, 1) {
+		suite.Equal("G001", response.Findings[0].RuleID)
+		suite.Equal("Jane Doe", response.Findings[0].Commit.AuthorName)
+	}
+	// the next page starts after the last issue of the page
+	suite.Equal("1", response.NextCursor)
+}
+
+func (suite *ServiceTestSuite) TestListIssuesReportNotFound() {
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	_, err := suite.service.ListIssues(context.Background(), "fake-uuid", &model.IssueFilter{Limit: 100})
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *ServiceTestSuite) TestEvents() {
+	events := make(chan *model.Progress)
+	suite.broker.EXPECT().Subscribe(gomock.Any(), "fake-uuid").Return(events, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{ID: "fake-uuid"}, nil)
+
+	r, ch, err := suite.service.Events(context.Background(), "fake-uuid")
+	suite.NoError(err)
+	suite.Equal("fake-uuid", r.ID)
+	suite.NotNil(ch)
+}
+
+func (suite *ServiceTestSuite) TestEventsReportNotFound() {
+	suite.broker.EXPECT().Subscribe(gomock.Any(), "fake-uuid").Return(make(chan *model.Progress), nil)
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	_, _, err := suite.service.Events(context.Background(), "fake-uuid")
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *ServiceTestSuite) TestEventsStream() {
+	events := make(chan *model.Progress, 3)
+	events <- &model.Progress{ReportID: "fake-uuid", Stage: model.ProgressScanning, FilesDiscovered: 4}
+	events <- &model.Progress{ReportID: "fake-uuid", Stage: model.ProgressScanning, FilesDiscovered: 4, FilesScanned: 2, Percent: 50}
+	events <- &model.Progress{ReportID: "fake-uuid", Stage: model.ProgressFinished, Percent: 100}
+
+	suite.broker.EXPECT().Subscribe(gomock.Any(), "fake-uuid").Return(events, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:     "fake-uuid",
+		Status: model.StatusInProgress,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid/events", nil))
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("text/event-stream", w.Header().Get("Content-Type"))
+
+	// the current progress is sent first, the stream ends with the finished event
+	body := w.Body.String()
+	suite.Equal(4, strings.Count(body, "event: progress\n"))
+	suite.True(strings.HasPrefix(body, "event: progress\ndata: {\"report_id\":\"fake-uuid\",\"stage\":\"cloning\""))
+	suite.Contains(body, `"percent":50`)
+	suite.True(strings.HasSuffix(body, "\"stage\":\"finished\",\"files_discovered\":0,\"files_scanned\":0,"+
+		"\"issues_found\":0,\"percent\":100,\"updated_at\":\"0001-01-01T00:00:00Z\"}\n\n"))
+}
+
+func (suite *ServiceTestSuite) TestEventsStreamFinishedReport() {
+	suite.broker.EXPECT().Subscribe(gomock.Any(), "fake-uuid").Return(make(chan *model.Progress), nil)
+	suite.report.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:     "fake-uuid",
+		Status: model.StatusFailed,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/fake-uuid/events", nil))
+
+	suite.Equal(1, strings.Count(w.Body.String(), "event: progress\n"))
+	suite.Contains(w.Body.String(), `"stage":"failed"`)
+}
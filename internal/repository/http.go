@@ -3,8 +3,11 @@ package repository
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/app/middleware"
 	"github.com/marktrs/gitsast/internal/model"
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bunrouter"
@@ -14,7 +17,7 @@ import (
 var _ HTTPHandler = (*httpHandler)(nil)
 
 var (
-	ErrInvalidParam = errors.New("error invalid parameter")
+	ErrInvalidParam = middleware.BadRequest(errors.New("error invalid parameter"))
 )
 
 // HTTPHandler defines methods for http handler of repository domain
@@ -26,7 +29,12 @@ type HTTPHandler interface {
 	Update(http.ResponseWriter, bunrouter.Request) error
 	Remove(http.ResponseWriter, bunrouter.Request) error
 	Scan(http.ResponseWriter, bunrouter.Request) error
+	CancelScan(http.ResponseWriter, bunrouter.Request) error
 	GetReport(http.ResponseWriter, bunrouter.Request) error
+	ListReports(http.ResponseWriter, bunrouter.Request) error
+	DiffReports(http.ResponseWriter, bunrouter.Request) error
+	SetBaseline(http.ResponseWriter, bunrouter.Request) error
+	SetPolicy(http.ResponseWriter, bunrouter.Request) error
 }
 
 type httpHandler struct {
@@ -145,7 +153,33 @@ func (h *httpHandler) Scan(w http.ResponseWriter, req bunrouter.Request) error {
 		return ErrInvalidParam
 	}
 
-	report, err := h.service.CreateReport(ctx, id)
+	// request body is optional, scan options fall back to the repository defaults
+	var r *ScanRequest
+	if err := json.NewDecoder(req.Body).Decode(&r); err != nil && err != io.EOF {
+		return err
+	}
+
+	report, err := h.service.CreateReport(ctx, id, r)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, &report)
+}
+
+// CancelScan implements HTTPHandler.CancelScan interface.
+func (h *httpHandler) CancelScan(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to cancel scan by repo ID")
+		return ErrInvalidParam
+	}
+
+	report, err := h.service.CancelScan(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -167,10 +201,113 @@ func (h *httpHandler) GetReport(w http.ResponseWriter, req bunrouter.Request) er
 		return ErrInvalidParam
 	}
 
-	response, err := h.service.GetReportByRepoId(ctx, id)
+	f, err := model.DecodeReportFilter(req)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.service.GetReportByRepoId(ctx, id, f)
 	if err != nil {
 		return err
 	}
 
 	return bunrouter.JSON(w, &response)
 }
+
+// ListReports implements HTTPHandler.ListReports interface.
+func (h *httpHandler) ListReports(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to list reports by repo ID")
+		return ErrInvalidParam
+	}
+
+	f, err := model.DecodeReportListFilter(req)
+	if err != nil {
+		return err
+	}
+
+	reports, total, err := h.service.ListReports(ctx, id, f)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, bunrouter.H{
+		"reports": &reports,
+		"total":   total,
+	})
+}
+
+// DiffReports implements HTTPHandler.DiffReports interface.
+func (h *httpHandler) DiffReports(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to diff reports by repo ID")
+		return ErrInvalidParam
+	}
+
+	reportId, ok := params["reportId"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to diff reports by report ID")
+		return ErrInvalidParam
+	}
+
+	baseId := req.URL.Query().Get("base")
+	if baseId == "" {
+		log.Err(ErrInvalidParam).Msg("unable to diff reports without base report ID")
+		return ErrInvalidParam
+	}
+
+	response, err := h.service.DiffReports(ctx, id, reportId, baseId)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, &response)
+}
+
+// SetBaseline implements HTTPHandler.SetBaseline interface.
+func (h *httpHandler) SetBaseline(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to set baseline by repo ID")
+		return ErrInvalidParam
+	}
+
+	var baseline *model.Baseline
+	if err := json.NewDecoder(req.Body).Decode(&baseline); err != nil {
+		return err
+	}
+
+	return h.service.SetBaseline(ctx, id, baseline)
+}
+
+// SetPolicy implements HTTPHandler.SetPolicy interface.
+func (h *httpHandler) SetPolicy(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to set policy by repo ID")
+		return ErrInvalidParam
+	}
+
+	var policy *app.Policy
+	if err := json.NewDecoder(req.Body).Decode(&policy); err != nil {
+		return err
+	}
+
+	return h.service.SetPolicy(ctx, id, policy)
+}
@@ -2,13 +2,26 @@ package repository
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/app/middleware"
 	"github.com/marktrs/gitsast/internal/model"
 	"github.com/uptrace/bunrouter"
 )
 
 func init() {
+	middleware.RegisterErrorStatus(http.StatusBadRequest,
+		model.ErrInvalidQueryParam,
+		model.ErrReportRepositoryMismatch,
+	)
+	middleware.RegisterErrorStatus(http.StatusConflict,
+		model.ErrReportInProgress,
+		model.ErrReportNotCompleted,
+		model.ErrReportNotCancellable,
+		model.ErrReportStatusChanged,
+	)
+
 	app.OnStart("repository.initRoutes", func(ctx context.Context, app *app.App) error {
 		rs := model.NewRepositoryRepo(app)
 		rp := model.NewReportRepo(app)
@@ -22,7 +35,12 @@ func init() {
 			g.PUT("/:id", h.Update)
 			g.DELETE("/:id", h.Remove)
 			g.POST("/:id/scan", h.Scan)
+			g.POST("/:id/scan/cancel", h.CancelScan)
+			g.PUT("/:id/baseline", h.SetBaseline)
+			g.PUT("/:id/policy", h.SetPolicy)
 			g.GET("/:id/report", h.GetReport)
+			g.GET("/:id/reports", h.ListReports)
+			g.GET("/:id/reports/:reportId/diff", h.DiffReports)
 		})
 
 		return nil
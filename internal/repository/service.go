@@ -26,8 +26,13 @@ type IService interface {
 	Add(ctx context.Context, req *AddRepositoryRequest) (*model.Repository, error)
 	Update(ctx context.Context, id string, req *UpdateRepositoryRequest) error
 	Remove(ctx context.Context, id string) error
-	CreateReport(ctx context.Context, repoId string) (*model.Report, error)
-	GetReportByRepoId(ctx context.Context, repoId string) (*GetReportResponse, error)
+	CreateReport(ctx context.Context, repoId string, req *ScanRequest) (*model.Report, error)
+	CancelScan(ctx context.Context, repoId string) (*model.Report, error)
+	GetReportByRepoId(ctx context.Context, repoId string, f *model.ReportFilter) (*model.Report, error)
+	ListReports(ctx context.Context, repoId string, f *model.ReportListFilter) ([]*model.Report, int, error)
+	DiffReports(ctx context.Context, repoId string, reportId string, baseId string) (*DiffReportResponse, error)
+	SetBaseline(ctx context.Context, repoId string, baseline *model.Baseline) error
+	SetPolicy(ctx context.Context, repoId string, policy *app.Policy) error
 }
 
 type service struct {
@@ -40,8 +45,9 @@ type service struct {
 }
 
 type AddRepositoryRequest struct {
-	Name      string `json:"name" validate:"required,max=120"`
-	RemoteURL string `json:"remote_url" validate:"required,max=120,is-git-url"`
+	Name        string             `json:"name" validate:"required,max=120"`
+	RemoteURL   string             `json:"remote_url" validate:"required,max=120,is-git-url"`
+	ScanOptions *model.ScanOptions `json:"scan_options" validate:"omitempty"`
 }
 
 func (r *AddRepositoryRequest) Validate(validator *validator.Validate) error {
@@ -49,16 +55,54 @@ func (r *AddRepositoryRequest) Validate(validator *validator.Validate) error {
 }
 
 type UpdateRepositoryRequest struct {
-	Name      string `json:"name" validate:"max=120"`
-	RemoteURL string `json:"remote_url" validate:"max=120,is-git-url"`
+	Name        string             `json:"name" validate:"max=120"`
+	RemoteURL   string             `json:"remote_url" validate:"max=120,is-git-url"`
+	ScanOptions *model.ScanOptions `json:"scan_options" validate:"omitempty"`
 }
 
 func (r *UpdateRepositoryRequest) Validate(validator *validator.Validate) error {
 	return validator.Struct(r)
 }
 
-func NewService(app *app.App, rs model.IRepositoryRepo, rp model.IReportRepo) IService {
+// ScanRequest holds optional scan options overriding the repository defaults
+type ScanRequest struct {
+	Submodules     *bool `json:"submodules"`
+	SubmoduleDepth *int  `json:"submodule_depth" validate:"omitempty,submodule-depth"`
+}
+
+func (r *ScanRequest) Validate(validator *validator.Validate) error {
+	return validator.Struct(r)
+}
+
+// options - merge the scan request into the repository default scan options
+func (r *ScanRequest) options(defaults *model.ScanOptions) *model.ScanOptions {
+	opts := &model.ScanOptions{}
+	if defaults != nil {
+		*opts = *defaults
+	}
+
+	if r == nil {
+		return opts
+	}
+
+	if r.Submodules != nil {
+		opts.Submodules = *r.Submodules
+	}
+
+	if r.SubmoduleDepth != nil {
+		opts.SubmoduleDepth = *r.SubmoduleDepth
+	}
+
+	return opts
+}
+
+func NewService(
+	app *app.App,
+	rs model.IRepositoryRepo,
+	rp model.IReportRepo,
+) IService {
 	app.Validator().RegisterValidation("is-git-url", ValidateGitRemoteURL)
+	app.Validator().RegisterValidation("submodule-depth", ValidateSubmoduleDepth)
 	return &service{
 		app:       app,
 		repo:      rs,
@@ -87,9 +131,10 @@ func (s *service) Add(ctx context.Context, req *AddRepositoryRequest) (*model.Re
 	}
 
 	repo := &model.Repository{
-		ID:        uuid.New().String(),
-		Name:      req.Name,
-		RemoteURL: req.RemoteURL,
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		RemoteURL:   req.RemoteURL,
+		ScanOptions: req.ScanOptions,
 	}
 
 	return s.repo.Add(ctx, repo)
@@ -113,6 +158,10 @@ func (s *service) Update(
 		"updated_at": time.Now(),
 	}
 
+	if req.ScanOptions != nil {
+		repo["scan_options"] = req.ScanOptions
+	}
+
 	return s.repo.Update(ctx, id, repo)
 }
 
@@ -122,13 +171,20 @@ func (s *service) Remove(ctx context.Context, id string) error {
 }
 
 // CreateReport implements IService.CreateReport interface.
-func (s *service) CreateReport(ctx context.Context, repoId string) (*model.Report, error) {
+func (s *service) CreateReport(ctx context.Context, repoId string, req *ScanRequest) (*model.Report, error) {
+	if req != nil {
+		if err := req.Validate(s.validator); err != nil {
+			log.Err(err).Msg("request validation failed on scan repository handler")
+			return nil, err
+		}
+	}
+
 	repo, err := s.repo.GetById(ctx, repoId)
 	if err != nil {
 		return nil, err
 	}
 
-	report, err := s.report.GetByRepoId(ctx, repo.ID)
+	report, err := s.report.GetLatestByRepoId(ctx, repo.ID)
 	if err != nil {
 		if err != sql.ErrNoRows {
 			return nil, err
@@ -137,18 +193,22 @@ func (s *service) CreateReport(ctx context.Context, repoId string) (*model.Repor
 
 	// if report exist
 	if report != nil {
-		if report.Status != model.StatusSuccess && report.Status != model.StatusFailed {
+		if !report.Status.IsFinished() {
 			return nil, model.ErrReportInProgress
 		}
 	}
 
-	// generate a new report
+	// generate a new report, it is stored as enqueued before its task is queued
+	// so that neither a worker nor a cancellation has to wait for it
+	now := time.Now()
 	report = &model.Report{
 		ID:           uuid.New().String(),
-		Status:       model.StatusInitialized,
+		Status:       model.StatusEnqueued,
 		RepositoryID: repoId,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		EnqueueAt:    now,
+		Options:      req.options(repo.ScanOptions),
 		Issues:       []*model.Issue{},
 	}
 
@@ -158,52 +218,188 @@ func (s *service) CreateReport(ctx context.Context, repoId string) (*model.Repor
 	}
 
 	// enqueue a new analyzing task to main queue
-	err = s.queue.AddTask(analyzer.Task.WithArgs(ctx, report.ID))
-	if err != nil {
+	if err := s.queue.AddTask(analyzer.Task.WithArgs(ctx, report.ID)); err != nil {
+		report.Status = model.StatusFailed
+		report.FailedReason = err.Error()
+		report.FinishedAt = time.Now()
+		if _, err := s.report.UpdateStatus(ctx, report); err != nil {
+			log.Err(err).Str("report_id", report.ID).Msg("unable to fail report which was not queued")
+		}
 		return nil, err
 	}
 
-	report.Status = model.StatusEnqueued
-	report.EnqueueAt = time.Now()
+	return report, nil
+}
+
+// CancelScan implements IService.CancelScan interface.
+func (s *service) CancelScan(ctx context.Context, repoId string) (*model.Report, error) {
+	repo, err := s.repo.GetById(ctx, repoId)
+	if err != nil {
+		return nil, err
+	}
 
-	report, err = s.report.Update(ctx, report)
+	report, err := s.report.GetLatestByRepoId(ctx, repo.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return report, nil
-}
+	if !report.Status.IsCancellable() {
+		return nil, model.ErrReportNotCancellable
+	}
+
+	// queues cannot delete a message before a worker reserves it, so the task of a queued
+	// report is taken once and deleted without scanning or retrying it. A running task
+	// polls the report status and stops scanning
+	if err := s.report.Cancel(ctx, report.ID, time.Now()); err != nil {
+		return nil, err
+	}
 
-type GetReportResponse struct {
-	model.Report
-	Findings []*model.Finding `json:"findings"`
+	return s.report.GetById(ctx, report.ID)
 }
 
 // GetReport - Implements IService.GetReport interface.
-func (s *service) GetReportByRepoId(ctx context.Context, id string) (*GetReportResponse, error) {
-	report, err := s.report.GetByRepoId(ctx, id)
+// The report is returned with its summary, its issues are listed by pages of the
+// issues endpoint of the report.
+func (s *service) GetReportByRepoId(
+	ctx context.Context,
+	id string,
+	f *model.ReportFilter,
+) (*model.Report, error) {
+	if f != nil && f.Latest == model.LatestSuccessful {
+		return s.report.GetLatestSuccessfulByRepoId(ctx, id)
+	}
+
+	return s.report.GetLatestByRepoId(ctx, id)
+}
+
+// ListReports - Implements IService.ListReports interface.
+func (s *service) ListReports(
+	ctx context.Context,
+	repoId string,
+	f *model.ReportListFilter,
+) ([]*model.Report, int, error) {
+	repo, err := s.repo.GetById(ctx, repoId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.report.ListByRepoId(ctx, repo.ID, f)
+}
+
+// SetBaseline - Implements IService.SetBaseline interface.
+func (s *service) SetBaseline(ctx context.Context, repoId string, baseline *model.Baseline) error {
+	if err := s.validator.Struct(baseline); err != nil {
+		log.Err(err).Msg("request validation failed on set baseline handler")
+		return err
+	}
+
+	if baseline.CreatedAt.IsZero() {
+		baseline.CreatedAt = time.Now()
+	}
+
+	repo := map[string]interface{}{
+		"baseline":   baseline,
+		"updated_at": time.Now(),
+	}
+
+	return s.repo.Update(ctx, repoId, repo)
+}
+
+// SetPolicy - Implements IService.SetPolicy interface.
+// A nil policy removes the policy of the repository, the global policy applies again.
+func (s *service) SetPolicy(ctx context.Context, repoId string, policy *app.Policy) error {
+	if policy != nil {
+		if err := s.validator.Struct(policy); err != nil {
+			log.Err(err).Msg("request validation failed on set policy handler")
+			return err
+		}
+	}
+
+	repo := map[string]interface{}{
+		"policy":     policy,
+		"updated_at": time.Now(),
+	}
+
+	return s.repo.Update(ctx, repoId, repo)
+}
+
+type DiffReportResponse struct {
+	ReportID     string           `json:"report_id"`
+	BaseReportID string           `json:"base_report_id"`
+	Summary      DiffSummary      `json:"summary"`
+	New          []*model.Finding `json:"new"`
+	Fixed        []*model.Finding `json:"fixed"`
+	Unchanged    []*model.Finding `json:"unchanged"`
+}
+
+type DiffSummary struct {
+	New       int `json:"new"`
+	Fixed     int `json:"fixed"`
+	Unchanged int `json:"unchanged"`
+	// NewBySeverity counts new findings by severity, e.g. to gate merges on new HIGH findings
+	NewBySeverity map[string]int `json:"new_by_severity"`
+}
+
+// DiffReports - Implements IService.DiffReports interface.
+func (s *service) DiffReports(
+	ctx context.Context,
+	repoId string,
+	reportId string,
+	baseId string,
+) (*DiffReportResponse, error) {
+	report, err := s.getCompletedReport(ctx, repoId, reportId)
 	if err != nil {
 		return nil, err
 	}
 
-	var findings []*model.Finding
-	for _, issue := range report.Issues {
-		var finding model.Finding
-		finding.Type = "sast"
-		finding.RuleID = issue.RuleID
-		finding.Location.Path = issue.Location.Path
-		finding.Location.Position.Begin.Line = int(issue.Location.Line)
-		finding.Metadata.Description = issue.Description
-		finding.Metadata.Severity = issue.Severity
-		findings = append(findings, &finding)
+	base, err := s.getCompletedReport(ctx, repoId, baseId)
+	if err != nil {
+		return nil, err
 	}
 
-	var response GetReportResponse
-	response.Report = *report
-	response.Issues = nil
-	response.Findings = findings
+	diff := model.DiffIssues(base.Issues, report.Issues)
+
+	newBySeverity := make(map[string]int)
+	for _, issue := range diff.New {
+		newBySeverity[issue.Severity]++
+	}
 
-	return &response, nil
+	return &DiffReportResponse{
+		ReportID:     report.ID,
+		BaseReportID: base.ID,
+		Summary: DiffSummary{
+			New:           len(diff.New),
+			Fixed:         len(diff.Fixed),
+			Unchanged:     len(diff.Unchanged),
+			NewBySeverity: newBySeverity,
+		},
+		New:       model.NewFindings(diff.New),
+		Fixed:     model.NewFindings(diff.Fixed),
+		Unchanged: model.NewFindings(diff.Unchanged),
+	}, nil
+}
+
+// getCompletedReport - get a successful report belonging to the repository
+func (s *service) getCompletedReport(ctx context.Context, repoId, reportId string) (*model.Report, error) {
+	report, err := s.report.GetById(ctx, reportId)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.RepositoryID != repoId {
+		return nil, model.ErrReportRepositoryMismatch
+	}
+
+	if report.Status != model.StatusSuccess {
+		return nil, model.ErrReportNotCompleted
+	}
+
+	report.Issues, err = s.report.GetIssues(ctx, report.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
 }
 
 func ValidateGitRemoteURL(fl validator.FieldLevel) bool {
@@ -217,3 +413,9 @@ func ValidateGitRemoteURL(fl validator.FieldLevel) bool {
 
 	return len(matches) != 0
 }
+
+// ValidateSubmoduleDepth - check the submodule depth is between 0 and model.MaxSubmoduleDepth
+func ValidateSubmoduleDepth(fl validator.FieldLevel) bool {
+	depth := fl.Field().Int()
+	return depth >= 0 && depth <= model.MaxSubmoduleDepth
+}
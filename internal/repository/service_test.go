@@ -2,9 +2,13 @@ package repository_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/marktrs/gitsast/app"
 	"github.com/marktrs/gitsast/internal/model"
 	"github.com/marktrs/gitsast/internal/repository"
 
@@ -95,35 +99,274 @@ func (suite *ServiceTestSuite) TestCreateReport() {
 	suite.repo.EXPECT().
 		GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{}, nil)
 	suite.report.EXPECT().
-		GetByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+		GetLatestByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
 	suite.report.EXPECT().
-		Add(gomock.Any(), gomock.Any()).Return(&model.Report{}, nil)
+		Add(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			// the report is enqueued before its task is queued
+			suite.Equal(model.StatusEnqueued, r.Status)
+			suite.False(r.EnqueueAt.IsZero())
+			return r, nil
+		})
+	suite.queue.EXPECT().AddTask(gomock.Any()).Return(nil)
+
+	report, err := suite.service.CreateReport(context.Background(), "fake-uuid", nil)
+	suite.NoError(err)
+	suite.Equal(model.StatusEnqueued, report.Status)
+}
+
+func (suite *ServiceTestSuite) TestCreateReportQueueError() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.report.EXPECT().GetLatestByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.report.EXPECT().Add(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			return r, nil
+		})
+	errQueue := errors.New("dial tcp :6379: connect: connection refused")
+	suite.queue.EXPECT().AddTask(gomock.Any()).Return(errQueue)
+
+	// the report which was not queued fails so that the repository can be scanned again
+	suite.report.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			suite.Equal(model.StatusFailed, r.Status)
+			suite.Equal(errQueue.Error(), r.FailedReason)
+			return r, nil
+		})
+
+	_, err := suite.service.CreateReport(context.Background(), "fake-uuid", nil)
+	suite.ErrorIs(err, errQueue)
+}
+
+func (suite *ServiceTestSuite) TestCreateReportAfterCancelled() {
+	suite.repo.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.report.EXPECT().GetLatestByRepoId(gomock.Any(), gomock.Any()).Return(&model.Report{
+		Status: model.StatusCancelled,
+	}, nil)
+	suite.report.EXPECT().Add(gomock.Any(), gomock.Any()).Return(&model.Report{}, nil)
 	suite.queue.EXPECT().AddTask(gomock.Any()).Return(nil)
-	suite.report.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil, nil)
 
-	_, err := suite.service.CreateReport(context.Background(), "fake-uuid")
+	_, err := suite.service.CreateReport(context.Background(), "fake-uuid", nil)
 	suite.NoError(err)
 }
 
+func (suite *ServiceTestSuite) TestCancelScan() {
+	for _, status := range []model.ReportStatus{
+		model.StatusInitialized,
+		model.StatusEnqueued,
+		model.StatusInProgress,
+	} {
+		suite.Run(string(status), func() {
+			suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+			suite.report.EXPECT().GetLatestByRepoId(gomock.Any(), "fake-uuid").Return(&model.Report{
+				ID:     "fake-report-uuid",
+				Status: status,
+			}, nil)
+			// only the status is written, the report is read back with the latest progress
+			suite.report.EXPECT().Cancel(gomock.Any(), "fake-report-uuid", gomock.Any()).Return(nil)
+			suite.report.EXPECT().GetById(gomock.Any(), "fake-report-uuid").Return(&model.Report{
+				ID:         "fake-report-uuid",
+				Status:     model.StatusCancelled,
+				FinishedAt: time.Now(),
+			}, nil)
+
+			report, err := suite.service.CancelScan(context.Background(), "fake-uuid")
+			suite.NoError(err)
+			suite.Equal(model.StatusCancelled, report.Status)
+			suite.False(report.FinishedAt.IsZero())
+		})
+	}
+}
+
+func (suite *ServiceTestSuite) TestCancelScanFinishedMeanwhile() {
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.report.EXPECT().GetLatestByRepoId(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:     "fake-report-uuid",
+		Status: model.StatusInProgress,
+	}, nil)
+	// the scan succeeded after the report was read
+	suite.report.EXPECT().Cancel(gomock.Any(), "fake-report-uuid", gomock.Any()).Return(model.ErrReportNotCancellable)
+
+	_, err := suite.service.CancelScan(context.Background(), "fake-uuid")
+	suite.ErrorIs(err, model.ErrReportNotCancellable)
+}
+
+func (suite *ServiceTestSuite) TestCancelScanFinishedReport() {
+	for _, status := range []model.ReportStatus{
+		model.StatusSuccess,
+		model.StatusFailed,
+		model.StatusCancelled,
+	} {
+		suite.Run(string(status), func() {
+			suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+			suite.report.EXPECT().GetLatestByRepoId(gomock.Any(), "fake-uuid").Return(&model.Report{
+				Status: status,
+			}, nil)
+
+			_, err := suite.service.CancelScan(context.Background(), "fake-uuid")
+			suite.ErrorIs(err, model.ErrReportNotCancellable)
+		})
+	}
+}
+
 func (suite *ServiceTestSuite) TestGetReport() {
+	suite.report.EXPECT().GetLatestByRepoId(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:      "report-uuid",
+		Status:  model.StatusSuccess,
+		Summary: &model.Summary{TotalIssues: 1, IssuesBySeverity: map[string]int{"LOW": 1}},
+	}, nil)
+
+	// the issues are not loaded, they are listed by the issues endpoint of the report
+	report, err := suite.service.GetReportByRepoId(context.Background(), "fake-uuid", nil)
+	suite.NoError(err)
+	suite.Equal("report-uuid", report.ID)
+	suite.Equal(1, report.Summary.TotalIssues)
+	suite.Nil(report.Issues)
+}
+
+func (suite *ServiceTestSuite) TestGetLatestSuccessfulReport() {
+	suite.report.EXPECT().GetLatestSuccessfulByRepoId(gomock.Any(), "fake-uuid").Return(&model.Report{
+		ID:     "successful-uuid",
+		Status: model.StatusSuccess,
+	}, nil)
+
+	report, err := suite.service.GetReportByRepoId(
+		context.Background(), "fake-uuid", &model.ReportFilter{Latest: model.LatestSuccessful})
+	suite.NoError(err)
+	suite.Equal("successful-uuid", report.ID)
+}
+
+func (suite *ServiceTestSuite) TestListReports() {
+	f := &model.ReportListFilter{Order: "desc", Limit: 2}
+	reports := []*model.Report{{ID: "second-uuid"}, {ID: "first-uuid"}}
+
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.report.EXPECT().ListByRepoId(gomock.Any(), "fake-uuid", f).Return(reports, 5, nil)
+
+	result, total, err := suite.service.ListReports(context.Background(), "fake-uuid", f)
+	suite.NoError(err)
+	suite.Equal(reports, result)
+	suite.Equal(5, total)
+}
+
+func (suite *ServiceTestSuite) TestListReportsRepositoryNotFound() {
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	_, _, err := suite.service.ListReports(context.Background(), "fake-uuid", &model.ReportListFilter{})
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *ServiceTestSuite) TestDiffReports() {
+	base := &model.Report{
+		ID:           "base-uuid",
+		RepositoryID: "fake-uuid",
+		Status:       model.StatusSuccess,
+	}
 	report := &model.Report{
-		Issues: []*model.Issue{
-			{
-				RuleID: "G001",
-				Location: model.Location{
-					Path: "pub.key",
-					Line: 0,
-				},
-				Description: "Public key leak",
-				Severity:    "LOW",
-				Keyword:     `public_key`,
-			},
-		},
+		ID:           "report-uuid",
+		RepositoryID: "fake-uuid",
+		Status:       model.StatusSuccess,
 	}
 
-	suite.report.EXPECT().GetByRepoId(gomock.Any(), gomock.Any()).Return(report, nil)
-	_, err := suite.service.GetReportByRepoId(context.Background(), "fake-uuid")
+	suite.report.EXPECT().GetById(gomock.Any(), "report-uuid").Return(report, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "report-uuid").Return([]*model.Issue{
+		{RuleID: "G001", Severity: "LOW", Fingerprint: "kept"},
+		{RuleID: "G002", Severity: "HIGH", Fingerprint: "added"},
+	}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), "base-uuid").Return(base, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "base-uuid").Return([]*model.Issue{
+		{RuleID: "G001", Severity: "LOW", Fingerprint: "kept"},
+		{RuleID: "G002", Severity: "HIGH", Fingerprint: "fixed"},
+	}, nil)
+
+	diff, err := suite.service.DiffReports(context.Background(), "fake-uuid", "report-uuid", "base-uuid")
 	suite.NoError(err)
+	suite.Equal(repository.DiffSummary{
+		New:           1,
+		Fixed:         1,
+		Unchanged:     1,
+		NewBySeverity: map[string]int{"HIGH": 1},
+	}, diff.Summary)
+	suite.Equal("added", diff.New[0].Fingerprint)
+	suite.Equal("fixed", diff.Fixed[0].Fingerprint)
+	suite.Equal("kept", diff.Unchanged[0].Fingerprint)
+}
+
+func (suite *ServiceTestSuite) TestDiffReportsError() {
+	suite.report.EXPECT().GetById(gomock.Any(), "other-uuid").Return(&model.Report{
+		RepositoryID: "other-repo-uuid",
+		Status:       model.StatusSuccess,
+	}, nil)
+	_, err := suite.service.DiffReports(context.Background(), "fake-uuid", "other-uuid", "base-uuid")
+	suite.ErrorIs(err, model.ErrReportRepositoryMismatch)
+
+	suite.report.EXPECT().GetById(gomock.Any(), "report-uuid").Return(&model.Report{
+		ID:           "report-uuid",
+		RepositoryID: "fake-uuid",
+		Status:       model.StatusSuccess,
+	}, nil)
+	suite.report.EXPECT().GetIssues(gomock.Any(), "report-uuid").Return([]*model.Issue{}, nil)
+	suite.report.EXPECT().GetById(gomock.Any(), "base-uuid").Return(&model.Report{
+		RepositoryID: "fake-uuid",
+		Status:       model.StatusInProgress,
+	}, nil)
+	_, err = suite.service.DiffReports(context.Background(), "fake-uuid", "report-uuid", "base-uuid")
+	suite.ErrorIs(err, model.ErrReportNotCompleted)
+}
+
+func (suite *ServiceTestSuite) TestSetBaseline() {
+	fingerprint := model.NewFingerprint("G001", "pub.key", "public_key=abc")
+
+	suite.repo.EXPECT().
+		Update(gomock.Any(), "fake-uuid", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, repo map[string]interface{}) error {
+			baseline := repo["baseline"].(*model.Baseline)
+			suite.Equal([]string{fingerprint}, baseline.Fingerprints)
+			suite.False(baseline.CreatedAt.IsZero())
+			return nil
+		})
+
+	err := suite.service.SetBaseline(context.Background(), "fake-uuid", &model.Baseline{
+		Fingerprints: []string{fingerprint},
+	})
+	suite.NoError(err)
+
+	err = suite.service.SetBaseline(context.Background(), "fake-uuid", &model.Baseline{
+		Fingerprints: []string{"not-a-fingerprint"},
+	})
+	suite.ErrorContains(err, "Field validation for 'Fingerprints[0]' failed on the 'len' tag")
+}
+
+func (suite *ServiceTestSuite) TestSetPolicy() {
+	policy := &app.Policy{Clauses: []*app.PolicyClause{{Severity: "HIGH"}}}
+
+	gomock.InOrder(
+		suite.repo.EXPECT().
+			Update(gomock.Any(), "fake-uuid", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, repo map[string]interface{}) error {
+				suite.Equal(policy, repo["policy"])
+				return nil
+			}),
+		// a null policy falls back to the global policy
+		suite.repo.EXPECT().
+			Update(gomock.Any(), "fake-uuid", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, repo map[string]interface{}) error {
+				suite.Nil(repo["policy"])
+				return nil
+			}),
+	)
+
+	suite.NoError(suite.service.SetPolicy(context.Background(), "fake-uuid", policy))
+	suite.NoError(suite.service.SetPolicy(context.Background(), "fake-uuid", nil))
+
+	err := suite.service.SetPolicy(context.Background(), "fake-uuid", &app.Policy{
+		Clauses: []*app.PolicyClause{{Severity: "CRITICAL"}},
+	})
+	suite.ErrorContains(err, "Field validation for 'Severity' failed on the 'oneof' tag")
+
+	err = suite.service.SetPolicy(context.Background(), "fake-uuid", &app.Policy{
+		Clauses: []*app.PolicyClause{{MaxCount: -1}},
+	})
+	suite.ErrorContains(err, "Field validation for 'MaxCount' failed on the 'gte' tag")
 }
 
 func (suite *ServiceTestSuite) TestAddRepositoryRequestValidation() {
@@ -297,3 +540,49 @@ func (suite *ServiceTestSuite) TestUpdateRepositoryRequestValidation() {
 
 	}
 }
+
+func (suite *ServiceTestSuite) TestCreateReportWithScanOptions() {
+	submodules := true
+	depth := 3
+
+	suite.repo.EXPECT().
+		GetById(gomock.Any(), gomock.Any()).Return(&model.Repository{
+		ScanOptions: &model.ScanOptions{SubmoduleDepth: 1},
+	}, nil)
+	suite.report.EXPECT().
+		GetLatestByRepoId(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.report.EXPECT().
+		Add(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r *model.Report) (*model.Report, error) {
+			suite.Equal(&model.ScanOptions{Submodules: true, SubmoduleDepth: 3}, r.Options)
+			return r, nil
+		})
+	suite.queue.EXPECT().AddTask(gomock.Any()).Return(nil)
+
+	_, err := suite.service.CreateReport(context.Background(), "fake-uuid", &repository.ScanRequest{
+		Submodules:     &submodules,
+		SubmoduleDepth: &depth,
+	})
+	suite.NoError(err)
+
+	depth = model.MaxSubmoduleDepth + 1
+	_, err = suite.service.CreateReport(context.Background(), "fake-uuid", &repository.ScanRequest{
+		SubmoduleDepth: &depth,
+	})
+	suite.ErrorContains(err, "Field validation for 'SubmoduleDepth' failed on the 'submodule-depth' tag")
+
+	depth = -1
+	_, err = suite.service.CreateReport(context.Background(), "fake-uuid", &repository.ScanRequest{
+		SubmoduleDepth: &depth,
+	})
+	suite.ErrorContains(err, "Field validation for 'SubmoduleDepth' failed on the 'submodule-depth' tag")
+}
+
+func (suite *ServiceTestSuite) TestAddRepositorySubmoduleDepthValidation() {
+	_, err := suite.service.Add(context.Background(), &repository.AddRepositoryRequest{
+		Name:        "lorem",
+		RemoteURL:   "https://github.com/test/test.git",
+		ScanOptions: &model.ScanOptions{Submodules: true, SubmoduleDepth: model.MaxSubmoduleDepth + 1},
+	})
+	suite.ErrorContains(err, "Field validation for 'SubmoduleDepth' failed on the 'submodule-depth' tag")
+}
@@ -0,0 +1,118 @@
+package triage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/marktrs/gitsast/app/middleware"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bunrouter"
+)
+
+// HTTPHandler variable that does static check to make sure that httpHandler struct implements HTTPHandler interface.
+var _ HTTPHandler = (*httpHandler)(nil)
+
+var (
+	ErrInvalidParam = middleware.BadRequest(errors.New("error invalid parameter"))
+)
+
+// HTTPHandler defines methods for http handler of triage domain
+// such as parse request, query and create response
+type HTTPHandler interface {
+	List(http.ResponseWriter, bunrouter.Request) error
+	Update(http.ResponseWriter, bunrouter.Request) error
+	AddComment(http.ResponseWriter, bunrouter.Request) error
+}
+
+type httpHandler struct {
+	service IService
+}
+
+func NewHTTPHandler(s IService) HTTPHandler {
+	return &httpHandler{
+		service: s,
+	}
+}
+
+// List implements HTTPHandler.List interface.
+func (h *httpHandler) List(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to list triage by repo ID")
+		return ErrInvalidParam
+	}
+
+	triages, err := h.service.List(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, bunrouter.H{
+		"triage": &triages,
+		"total":  len(triages),
+	})
+}
+
+// Update implements HTTPHandler.Update interface.
+func (h *httpHandler) Update(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to update triage by repo ID")
+		return ErrInvalidParam
+	}
+
+	fingerprint, ok := params["fingerprint"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to update triage by fingerprint")
+		return ErrInvalidParam
+	}
+
+	var r *UpdateTriageRequest
+	if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+		return err
+	}
+
+	triage, err := h.service.Update(ctx, id, fingerprint, r)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, triage)
+}
+
+// AddComment implements HTTPHandler.AddComment interface.
+func (h *httpHandler) AddComment(w http.ResponseWriter, req bunrouter.Request) error {
+	ctx := req.Context()
+
+	params := req.Params().Map()
+	id, ok := params["id"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to comment triage by repo ID")
+		return ErrInvalidParam
+	}
+
+	fingerprint, ok := params["fingerprint"]
+	if !ok {
+		log.Err(ErrInvalidParam).Msg("unable to comment triage by fingerprint")
+		return ErrInvalidParam
+	}
+
+	var r *AddCommentRequest
+	if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+		return err
+	}
+
+	triage, err := h.service.AddComment(ctx, id, fingerprint, r)
+	if err != nil {
+		return err
+	}
+
+	return bunrouter.JSON(w, triage)
+}
@@ -0,0 +1,26 @@
+package triage
+
+import (
+	"context"
+
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/uptrace/bunrouter"
+)
+
+func init() {
+	app.OnStart("triage.initRoutes", func(ctx context.Context, app *app.App) error {
+		rs := model.NewRepositoryRepo(app)
+		tr := model.NewTriageRepo(app)
+		s := NewService(app, rs, tr)
+		h := NewHTTPHandler(s)
+
+		app.APIRouter().WithGroup("/repository/:id", func(g *bunrouter.Group) {
+			g.GET("/triage", h.List)
+			g.PUT("/findings/:fingerprint/triage", h.Update)
+			g.POST("/findings/:fingerprint/comments", h.AddComment)
+		})
+
+		return nil
+	})
+}
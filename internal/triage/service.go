@@ -0,0 +1,174 @@
+package triage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/marktrs/gitsast/app"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/rs/zerolog/log"
+)
+
+// IService variable that does static check to make sure that 'service' struct implements 'IService' interface.
+var _ IService = (*service)(nil)
+
+// IService defines methods for business logic of triage domain
+// such as validate request body, change triage state of a finding and discuss it
+type IService interface {
+	List(ctx context.Context, repoId string) ([]*model.Triage, error)
+	Update(ctx context.Context, repoId string, fingerprint string, req *UpdateTriageRequest) (*model.Triage, error)
+	AddComment(ctx context.Context, repoId string, fingerprint string, req *AddCommentRequest) (*model.Triage, error)
+}
+
+type service struct {
+	app *app.App
+
+	repo      model.IRepositoryRepo
+	triage    model.ITriageRepo
+	validator *validator.Validate
+}
+
+type UpdateTriageRequest struct {
+	State     model.TriageState  `json:"state" validate:"required,oneof=open confirmed false_positive accepted_risk fixed"`
+	Assignee  string             `json:"assignee" validate:"max=120"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+	Comment   *AddCommentRequest `json:"comment" validate:"omitempty"`
+}
+
+func (r *UpdateTriageRequest) Validate(validator *validator.Validate) error {
+	return validator.Struct(r)
+}
+
+type AddCommentRequest struct {
+	Author string `json:"author" validate:"required,max=120"`
+	Body   string `json:"body" validate:"required,max=4000"`
+}
+
+func (r *AddCommentRequest) Validate(validator *validator.Validate) error {
+	return validator.Struct(r)
+}
+
+func NewService(app *app.App, rs model.IRepositoryRepo, tr model.ITriageRepo) IService {
+	return &service{
+		app:       app,
+		repo:      rs,
+		triage:    tr,
+		validator: app.Validator(),
+	}
+}
+
+// List implements IService.List interface.
+func (s *service) List(ctx context.Context, repoId string) ([]*model.Triage, error) {
+	return s.triage.GetByRepoId(ctx, repoId)
+}
+
+// Update implements IService.Update interface.
+func (s *service) Update(
+	ctx context.Context,
+	repoId string,
+	fingerprint string,
+	req *UpdateTriageRequest,
+) (*model.Triage, error) {
+	// validate request body
+	if err := req.Validate(s.validator); err != nil {
+		log.Err(err).Msg("request validation failed on update triage handler")
+		return nil, err
+	}
+
+	triage, err := s.getOrCreate(ctx, repoId, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.app.Clock().Now()
+
+	triage.State = req.State
+	triage.Assignee = req.Assignee
+	triage.ExpiresAt = time.Time{}
+	if req.ExpiresAt != nil {
+		triage.ExpiresAt = *req.ExpiresAt
+	}
+	triage.UpdatedAt = now
+
+	if req.Comment != nil {
+		triage.Comments = append(triage.Comments, &model.TriageComment{
+			Author:    req.Comment.Author,
+			Body:      req.Comment.Body,
+			CreatedAt: now,
+		})
+	}
+
+	return s.triage.Upsert(ctx, triage)
+}
+
+// AddComment implements IService.AddComment interface.
+func (s *service) AddComment(
+	ctx context.Context,
+	repoId string,
+	fingerprint string,
+	req *AddCommentRequest,
+) (*model.Triage, error) {
+	// validate request body
+	if err := req.Validate(s.validator); err != nil {
+		log.Err(err).Msg("request validation failed on add triage comment handler")
+		return nil, err
+	}
+
+	triage, err := s.getOrCreate(ctx, repoId, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &model.TriageComment{
+		RepositoryID: repoId,
+		Fingerprint:  fingerprint,
+		Author:       req.Author,
+		Body:         req.Body,
+		CreatedAt:    s.app.Clock().Now(),
+	}
+
+	// the comment is inserted on its own, a concurrent update of the triage is kept
+	if err := s.triage.AddComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	triage.UpdatedAt = comment.CreatedAt
+	triage.Comments = append(triage.Comments, comment)
+
+	return triage, nil
+}
+
+// getOrCreate - get the triage of a finding, or a new open triage if the finding was never triaged
+func (s *service) getOrCreate(ctx context.Context, repoId string, fingerprint string) (*model.Triage, error) {
+	if err := s.validator.Var(fingerprint, "len=64,hexadecimal"); err != nil {
+		log.Err(err).Msg("invalid fingerprint on triage handler")
+		return nil, err
+	}
+
+	// make sure the repository exists
+	if _, err := s.repo.GetById(ctx, repoId); err != nil {
+		return nil, err
+	}
+
+	triage, err := s.triage.Get(ctx, repoId, fingerprint)
+	if err == nil {
+		return triage, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	now := s.app.Clock().Now()
+
+	return &model.Triage{
+		RepositoryID: repoId,
+		Fingerprint:  fingerprint,
+		State:        model.TriageOpen,
+		Comments:     []*model.TriageComment{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
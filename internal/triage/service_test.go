@@ -0,0 +1,172 @@
+package triage_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/marktrs/gitsast/internal/model"
+	"github.com/marktrs/gitsast/internal/triage"
+	"github.com/stretchr/testify/suite"
+
+	mocks "github.com/marktrs/gitsast/testutil/mocks"
+	modelMock "github.com/marktrs/gitsast/testutil/mocks/model"
+)
+
+var fingerprint = strings.Repeat("a1", 32)
+
+type ServiceTestSuite struct {
+	suite.Suite
+
+	ctrl    *gomock.Controller
+	repo    *modelMock.MockIRepositoryRepo
+	triage  *modelMock.MockITriageRepo
+	testApp *mocks.TestApp
+
+	service triage.IService
+}
+
+func TestServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ServiceTestSuite))
+}
+
+func (suite *ServiceTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.repo = modelMock.NewMockIRepositoryRepo(suite.ctrl)
+	suite.triage = modelMock.NewMockITriageRepo(suite.ctrl)
+	suite.testApp = mocks.StartTestApp(context.Background())
+
+	suite.service = triage.NewService(suite.testApp.App, suite.repo, suite.triage)
+}
+
+func (suite *ServiceTestSuite) TearDownTest() {
+	suite.ctrl.Finish()
+}
+
+func (suite *ServiceTestSuite) TestList() {
+	suite.triage.EXPECT().GetByRepoId(gomock.Any(), "fake-uuid").Return(nil, nil)
+	_, err := suite.service.List(context.Background(), "fake-uuid")
+	suite.NoError(err)
+}
+
+func (suite *ServiceTestSuite) TestUpdateNewTriage() {
+	expiresAt := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.triage.EXPECT().Get(gomock.Any(), "fake-uuid", fingerprint).Return(nil, sql.ErrNoRows)
+	suite.triage.EXPECT().Upsert(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, t *model.Triage) (*model.Triage, error) {
+			return t, nil
+		})
+
+	t, err := suite.service.Update(context.Background(), "fake-uuid", fingerprint, &triage.UpdateTriageRequest{
+		State:     model.TriageAcceptedRisk,
+		Assignee:  "jane",
+		ExpiresAt: &expiresAt,
+		Comment:   &triage.AddCommentRequest{Author: "jane", Body: "accepted until the vendor rotates the key"},
+	})
+	suite.NoError(err)
+	suite.Equal("fake-uuid", t.RepositoryID)
+	suite.Equal(fingerprint, t.Fingerprint)
+	suite.Equal(model.TriageAcceptedRisk, t.State)
+	suite.Equal("jane", t.Assignee)
+	suite.Equal(expiresAt, t.ExpiresAt)
+	suite.Len(t.Comments, 1)
+	suite.Equal(suite.testApp.Clock().Now(), t.Comments[0].CreatedAt)
+}
+
+func (suite *ServiceTestSuite) TestUpdateExistingTriage() {
+	existing := &model.Triage{
+		RepositoryID: "fake-uuid",
+		Fingerprint:  fingerprint,
+		State:        model.TriageAcceptedRisk,
+		ExpiresAt:    time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC),
+		Comments:     []*model.TriageComment{{Author: "jane", Body: "accepted"}},
+	}
+
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.triage.EXPECT().Get(gomock.Any(), "fake-uuid", fingerprint).Return(existing, nil)
+	suite.triage.EXPECT().Upsert(gomock.Any(), existing).Return(existing, nil)
+
+	t, err := suite.service.Update(context.Background(), "fake-uuid", fingerprint, &triage.UpdateTriageRequest{
+		State: model.TriageFixed,
+	})
+	suite.NoError(err)
+	suite.Equal(model.TriageFixed, t.State)
+	suite.True(t.ExpiresAt.IsZero())
+	suite.Len(t.Comments, 1)
+}
+
+func (suite *ServiceTestSuite) TestAddComment() {
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(&model.Repository{ID: "fake-uuid"}, nil)
+	suite.triage.EXPECT().Get(gomock.Any(), "fake-uuid", fingerprint).Return(nil, sql.ErrNoRows)
+	// the triage decision is not written along with the comment
+	suite.triage.EXPECT().AddComment(gomock.Any(), &model.TriageComment{
+		RepositoryID: "fake-uuid",
+		Fingerprint:  fingerprint,
+		Author:       "john",
+		Body:         "looks like a test fixture",
+		CreatedAt:    suite.testApp.Clock().Now(),
+	}).Return(nil)
+
+	t, err := suite.service.AddComment(context.Background(), "fake-uuid", fingerprint, &triage.AddCommentRequest{
+		Author: "john",
+		Body:   "looks like a test fixture",
+	})
+	suite.NoError(err)
+	suite.Equal(model.TriageOpen, t.State)
+	suite.Len(t.Comments, 1)
+	suite.Equal("john", t.Comments[0].Author)
+}
+
+func (suite *ServiceTestSuite) TestRepositoryNotFound() {
+	suite.repo.EXPECT().GetById(gomock.Any(), "fake-uuid").Return(nil, sql.ErrNoRows)
+
+	_, err := suite.service.AddComment(context.Background(), "fake-uuid", fingerprint, &triage.AddCommentRequest{
+		Author: "john",
+		Body:   "looks like a test fixture",
+	})
+	suite.ErrorIs(err, sql.ErrNoRows)
+}
+
+func (suite *ServiceTestSuite) TestRequestValidation() {
+	testCases := []struct {
+		name        string
+		fingerprint string
+		req         *triage.UpdateTriageRequest
+	}{
+		{
+			name:        "missing state",
+			fingerprint: fingerprint,
+			req:         &triage.UpdateTriageRequest{},
+		},
+		{
+			name:        "invalid state",
+			fingerprint: fingerprint,
+			req:         &triage.UpdateTriageRequest{State: "ignored"},
+		},
+		{
+			name:        "invalid comment",
+			fingerprint: fingerprint,
+			req: &triage.UpdateTriageRequest{
+				State:   model.TriageConfirmed,
+				Comment: &triage.AddCommentRequest{Author: "jane"},
+			},
+		},
+		{
+			name:        "invalid fingerprint",
+			fingerprint: "not-a-fingerprint",
+			req:         &triage.UpdateTriageRequest{State: model.TriageConfirmed},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			_, err := suite.service.Update(context.Background(), "fake-uuid", tc.fingerprint, tc.req)
+			suite.Error(err)
+		})
+	}
+}
@@ -7,6 +7,7 @@ package testutil
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/marktrs/gitsast/internal/model"
@@ -50,6 +51,34 @@ func (mr *MockIReportRepoMockRecorder) Add(ctx, report interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockIReportRepo)(nil).Add), ctx, report)
 }
 
+// AddIssues mocks base method.
+func (m *MockIReportRepo) AddIssues(ctx context.Context, reportID string, issues []*model.Issue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIssues", ctx, reportID, issues)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddIssues indicates an expected call of AddIssues.
+func (mr *MockIReportRepoMockRecorder) AddIssues(ctx, reportID, issues interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIssues", reflect.TypeOf((*MockIReportRepo)(nil).AddIssues), ctx, reportID, issues)
+}
+
+// Cancel mocks base method.
+func (m *MockIReportRepo) Cancel(ctx context.Context, id string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, id, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockIReportRepoMockRecorder) Cancel(ctx, id, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockIReportRepo)(nil).Cancel), ctx, id, now)
+}
+
 // GetById mocks base method.
 func (m *MockIReportRepo) GetById(ctx context.Context, id string) (*model.Report, error) {
 	m.ctrl.T.Helper()
@@ -65,34 +94,80 @@ func (mr *MockIReportRepoMockRecorder) GetById(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockIReportRepo)(nil).GetById), ctx, id)
 }
 
-// GetByRepoId mocks base method.
-func (m *MockIReportRepo) GetByRepoId(ctx context.Context, id string) (*model.Report, error) {
+// GetIssues mocks base method.
+func (m *MockIReportRepo) GetIssues(ctx context.Context, reportID string) ([]*model.Issue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIssues", ctx, reportID)
+	ret0, _ := ret[0].([]*model.Issue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIssues indicates an expected call of GetIssues.
+func (mr *MockIReportRepoMockRecorder) GetIssues(ctx, reportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIssues", reflect.TypeOf((*MockIReportRepo)(nil).GetIssues), ctx, reportID)
+}
+
+// GetLatestByRepoId mocks base method.
+func (m *MockIReportRepo) GetLatestByRepoId(ctx context.Context, repoId string) (*model.Report, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByRepoId", ctx, id)
+	ret := m.ctrl.Call(m, "GetLatestByRepoId", ctx, repoId)
 	ret0, _ := ret[0].(*model.Report)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByRepoId indicates an expected call of GetByRepoId.
-func (mr *MockIReportRepoMockRecorder) GetByRepoId(ctx, id interface{}) *gomock.Call {
+// GetLatestByRepoId indicates an expected call of GetLatestByRepoId.
+func (mr *MockIReportRepoMockRecorder) GetLatestByRepoId(ctx, repoId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRepoId", reflect.TypeOf((*MockIReportRepo)(nil).GetByRepoId), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestByRepoId", reflect.TypeOf((*MockIReportRepo)(nil).GetLatestByRepoId), ctx, repoId)
 }
 
-// GetIssues mocks base method.
-func (m *MockIReportRepo) GetIssues(ctx context.Context, reportID string) ([]*model.Issue, error) {
+// GetLatestSuccessfulByRepoId mocks base method.
+func (m *MockIReportRepo) GetLatestSuccessfulByRepoId(ctx context.Context, repoId string) (*model.Report, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetIssues", ctx, reportID)
+	ret := m.ctrl.Call(m, "GetLatestSuccessfulByRepoId", ctx, repoId)
+	ret0, _ := ret[0].(*model.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestSuccessfulByRepoId indicates an expected call of GetLatestSuccessfulByRepoId.
+func (mr *MockIReportRepoMockRecorder) GetLatestSuccessfulByRepoId(ctx, repoId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestSuccessfulByRepoId", reflect.TypeOf((*MockIReportRepo)(nil).GetLatestSuccessfulByRepoId), ctx, repoId)
+}
+
+// ListByRepoId mocks base method.
+func (m *MockIReportRepo) ListByRepoId(ctx context.Context, repoId string, f *model.ReportListFilter) ([]*model.Report, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByRepoId", ctx, repoId, f)
+	ret0, _ := ret[0].([]*model.Report)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByRepoId indicates an expected call of ListByRepoId.
+func (mr *MockIReportRepoMockRecorder) ListByRepoId(ctx, repoId, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByRepoId", reflect.TypeOf((*MockIReportRepo)(nil).ListByRepoId), ctx, repoId, f)
+}
+
+// ListIssues mocks base method.
+func (m *MockIReportRepo) ListIssues(ctx context.Context, reportID string, f *model.IssueFilter) ([]*model.Issue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIssues", ctx, reportID, f)
 	ret0, _ := ret[0].([]*model.Issue)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetIssues indicates an expected call of GetIssues.
-func (mr *MockIReportRepoMockRecorder) GetIssues(ctx, reportID interface{}) *gomock.Call {
+// ListIssues indicates an expected call of ListIssues.
+func (mr *MockIReportRepoMockRecorder) ListIssues(ctx, reportID, f interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIssues", reflect.TypeOf((*MockIReportRepo)(nil).GetIssues), ctx, reportID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIssues", reflect.TypeOf((*MockIReportRepo)(nil).ListIssues), ctx, reportID, f)
 }
 
 // Update mocks base method.
@@ -109,3 +184,32 @@ func (mr *MockIReportRepoMockRecorder) Update(ctx, report interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIReportRepo)(nil).Update), ctx, report)
 }
+
+// UpdateProgress mocks base method.
+func (m *MockIReportRepo) UpdateProgress(ctx context.Context, id string, progress *model.Progress) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProgress", ctx, id, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProgress indicates an expected call of UpdateProgress.
+func (mr *MockIReportRepoMockRecorder) UpdateProgress(ctx, id, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProgress", reflect.TypeOf((*MockIReportRepo)(nil).UpdateProgress), ctx, id, progress)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockIReportRepo) UpdateStatus(ctx context.Context, report *model.Report) (*model.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, report)
+	ret0, _ := ret[0].(*model.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockIReportRepoMockRecorder) UpdateStatus(ctx, report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockIReportRepo)(nil).UpdateStatus), ctx, report)
+}
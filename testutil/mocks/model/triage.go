@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/model/triage.go
+
+// Package testutil is a generated GoMock package.
+package testutil
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/marktrs/gitsast/internal/model"
+)
+
+// MockITriageRepo is a mock of ITriageRepo interface.
+type MockITriageRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockITriageRepoMockRecorder
+}
+
+// MockITriageRepoMockRecorder is the mock recorder for MockITriageRepo.
+type MockITriageRepoMockRecorder struct {
+	mock *MockITriageRepo
+}
+
+// NewMockITriageRepo creates a new mock instance.
+func NewMockITriageRepo(ctrl *gomock.Controller) *MockITriageRepo {
+	mock := &MockITriageRepo{ctrl: ctrl}
+	mock.recorder = &MockITriageRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITriageRepo) EXPECT() *MockITriageRepoMockRecorder {
+	return m.recorder
+}
+
+// AddComment mocks base method.
+func (m *MockITriageRepo) AddComment(ctx context.Context, comment *model.TriageComment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddComment", ctx, comment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddComment indicates an expected call of AddComment.
+func (mr *MockITriageRepoMockRecorder) AddComment(ctx, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockITriageRepo)(nil).AddComment), ctx, comment)
+}
+
+// Get mocks base method.
+func (m *MockITriageRepo) Get(ctx context.Context, repoId, fingerprint string) (*model.Triage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, repoId, fingerprint)
+	ret0, _ := ret[0].(*model.Triage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockITriageRepoMockRecorder) Get(ctx, repoId, fingerprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockITriageRepo)(nil).Get), ctx, repoId, fingerprint)
+}
+
+// GetByRepoId mocks base method.
+func (m *MockITriageRepo) GetByRepoId(ctx context.Context, repoId string) ([]*model.Triage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRepoId", ctx, repoId)
+	ret0, _ := ret[0].([]*model.Triage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRepoId indicates an expected call of GetByRepoId.
+func (mr *MockITriageRepoMockRecorder) GetByRepoId(ctx, repoId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRepoId", reflect.TypeOf((*MockITriageRepo)(nil).GetByRepoId), ctx, repoId)
+}
+
+// Upsert mocks base method.
+func (m *MockITriageRepo) Upsert(ctx context.Context, triage *model.Triage) (*model.Triage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, triage)
+	ret0, _ := ret[0].(*model.Triage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockITriageRepoMockRecorder) Upsert(ctx, triage interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockITriageRepo)(nil).Upsert), ctx, triage)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/progress/broker.go
+
+// Package testutil is a generated GoMock package.
+package testutil
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/marktrs/gitsast/internal/model"
+)
+
+// MockIBroker is a mock of IBroker interface.
+type MockIBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockIBrokerMockRecorder
+}
+
+// MockIBrokerMockRecorder is the mock recorder for MockIBroker.
+type MockIBrokerMockRecorder struct {
+	mock *MockIBroker
+}
+
+// NewMockIBroker creates a new mock instance.
+func NewMockIBroker(ctrl *gomock.Controller) *MockIBroker {
+	mock := &MockIBroker{ctrl: ctrl}
+	mock.recorder = &MockIBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIBroker) EXPECT() *MockIBrokerMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockIBroker) Publish(ctx context.Context, progress *model.Progress) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockIBrokerMockRecorder) Publish(ctx, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockIBroker)(nil).Publish), ctx, progress)
+}
+
+// Subscribe mocks base method.
+func (m *MockIBroker) Subscribe(ctx context.Context, reportId string) (<-chan *model.Progress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, reportId)
+	ret0, _ := ret[0].(<-chan *model.Progress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockIBrokerMockRecorder) Subscribe(ctx, reportId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockIBroker)(nil).Subscribe), ctx, reportId)
+}
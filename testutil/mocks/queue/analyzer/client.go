@@ -5,9 +5,12 @@
 package testutil
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	model "github.com/marktrs/gitsast/internal/model"
+	git "github.com/marktrs/gitsast/internal/queue/task/analyzer/git"
 )
 
 // MockIClient is a mock of IClient interface.
@@ -33,17 +36,31 @@ func (m *MockIClient) EXPECT() *MockIClientMockRecorder {
 	return m.recorder
 }
 
+// BlameIssues mocks base method.
+func (m *MockIClient) BlameIssues(ctx context.Context, tmpDir string, issues []*model.Issue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlameIssues", ctx, tmpDir, issues)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlameIssues indicates an expected call of BlameIssues.
+func (mr *MockIClientMockRecorder) BlameIssues(ctx, tmpDir, issues interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlameIssues", reflect.TypeOf((*MockIClient)(nil).BlameIssues), ctx, tmpDir, issues)
+}
+
 // GetPathsFromRemoteURL mocks base method.
-func (m *MockIClient) GetPathsFromRemoteURL(tmpDir, remoteURL string) ([]string, error) {
+func (m *MockIClient) GetPathsFromRemoteURL(ctx context.Context, tmpDir, remoteURL string, opts *git.CloneOptions) (*git.Checkout, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPathsFromRemoteURL", tmpDir, remoteURL)
-	ret0, _ := ret[0].([]string)
+	ret := m.ctrl.Call(m, "GetPathsFromRemoteURL", ctx, tmpDir, remoteURL, opts)
+	ret0, _ := ret[0].(*git.Checkout)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPathsFromRemoteURL indicates an expected call of GetPathsFromRemoteURL.
-func (mr *MockIClientMockRecorder) GetPathsFromRemoteURL(tmpDir, remoteURL interface{}) *gomock.Call {
+func (mr *MockIClientMockRecorder) GetPathsFromRemoteURL(ctx, tmpDir, remoteURL, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPathsFromRemoteURL", reflect.TypeOf((*MockIClient)(nil).GetPathsFromRemoteURL), tmpDir, remoteURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPathsFromRemoteURL", reflect.TypeOf((*MockIClient)(nil).GetPathsFromRemoteURL), ctx, tmpDir, remoteURL, opts)
 }
@@ -5,6 +5,7 @@
 package testutil
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,18 +37,19 @@ func (m *MockScanner) EXPECT() *MockScannerMockRecorder {
 }
 
 // ScanFilesForIssues mocks base method.
-func (m *MockScanner) ScanFilesForIssues(tmpDir string, paths []string, rules []*model.Rule) ([]*model.Issue, error) {
+func (m *MockScanner) ScanFilesForIssues(ctx context.Context, root string, paths []string, rules []*model.Rule, onProgress analyzer.ProgressFunc) ([]*model.Issue, *analyzer.ScanStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ScanFilesForIssues", tmpDir, paths, rules)
+	ret := m.ctrl.Call(m, "ScanFilesForIssues", ctx, root, paths, rules, onProgress)
 	ret0, _ := ret[0].([]*model.Issue)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(*analyzer.ScanStats)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // ScanFilesForIssues indicates an expected call of ScanFilesForIssues.
-func (mr *MockScannerMockRecorder) ScanFilesForIssues(tmpDir, paths, rules interface{}) *gomock.Call {
+func (mr *MockScannerMockRecorder) ScanFilesForIssues(ctx, root, paths, rules, onProgress interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanFilesForIssues", reflect.TypeOf((*MockScanner)(nil).ScanFilesForIssues), tmpDir, paths, rules)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanFilesForIssues", reflect.TypeOf((*MockScanner)(nil).ScanFilesForIssues), ctx, root, paths, rules, onProgress)
 }
 
 // ScanLineForIssues mocks base method.
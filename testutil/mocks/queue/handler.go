@@ -5,7 +5,9 @@
 package testutil
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	taskq "github.com/vmihailenco/taskq/v3"
@@ -34,6 +36,20 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 	return m.recorder
 }
 
+// AddDeadLetter mocks base method.
+func (m *MockHandler) AddDeadLetter(t *taskq.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDeadLetter", t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddDeadLetter indicates an expected call of AddDeadLetter.
+func (mr *MockHandlerMockRecorder) AddDeadLetter(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDeadLetter", reflect.TypeOf((*MockHandler)(nil).AddDeadLetter), t)
+}
+
 // AddTask mocks base method.
 func (m *MockHandler) AddTask(t *taskq.Message) error {
 	m.ctrl.T.Helper()
@@ -48,16 +64,44 @@ func (mr *MockHandlerMockRecorder) AddTask(t interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTask", reflect.TypeOf((*MockHandler)(nil).AddTask), t)
 }
 
+// Close mocks base method.
+func (m *MockHandler) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockHandlerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockHandler)(nil).Close))
+}
+
 // StartConsumer mocks base method.
-func (m *MockHandler) StartConsumer() error {
+func (m *MockHandler) StartConsumer(ctx context.Context, concurrency int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StartConsumer")
+	ret := m.ctrl.Call(m, "StartConsumer", ctx, concurrency)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StartConsumer indicates an expected call of StartConsumer.
-func (mr *MockHandlerMockRecorder) StartConsumer() *gomock.Call {
+func (mr *MockHandlerMockRecorder) StartConsumer(ctx, concurrency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartConsumer", reflect.TypeOf((*MockHandler)(nil).StartConsumer), ctx, concurrency)
+}
+
+// StopConsumer mocks base method.
+func (m *MockHandler) StopConsumer(timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopConsumer", timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopConsumer indicates an expected call of StopConsumer.
+func (mr *MockHandlerMockRecorder) StopConsumer(timeout interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartConsumer", reflect.TypeOf((*MockHandler)(nil).StartConsumer))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopConsumer", reflect.TypeOf((*MockHandler)(nil).StopConsumer), timeout)
 }
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	app "github.com/marktrs/gitsast/app"
 	model "github.com/marktrs/gitsast/internal/model"
 	repository "github.com/marktrs/gitsast/internal/repository"
 )
@@ -51,19 +52,49 @@ func (mr *MockIServiceMockRecorder) Add(ctx, req interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockIService)(nil).Add), ctx, req)
 }
 
+// CancelScan mocks base method.
+func (m *MockIService) CancelScan(ctx context.Context, repoId string) (*model.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScan", ctx, repoId)
+	ret0, _ := ret[0].(*model.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScan indicates an expected call of CancelScan.
+func (mr *MockIServiceMockRecorder) CancelScan(ctx, repoId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScan", reflect.TypeOf((*MockIService)(nil).CancelScan), ctx, repoId)
+}
+
 // CreateReport mocks base method.
-func (m *MockIService) CreateReport(ctx context.Context, repoId string) (*model.Report, error) {
+func (m *MockIService) CreateReport(ctx context.Context, repoId string, req *repository.ScanRequest) (*model.Report, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateReport", ctx, repoId)
+	ret := m.ctrl.Call(m, "CreateReport", ctx, repoId, req)
 	ret0, _ := ret[0].(*model.Report)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateReport indicates an expected call of CreateReport.
-func (mr *MockIServiceMockRecorder) CreateReport(ctx, repoId interface{}) *gomock.Call {
+func (mr *MockIServiceMockRecorder) CreateReport(ctx, repoId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockIService)(nil).CreateReport), ctx, repoId, req)
+}
+
+// DiffReports mocks base method.
+func (m *MockIService) DiffReports(ctx context.Context, repoId, reportId, baseId string) (*repository.DiffReportResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffReports", ctx, repoId, reportId, baseId)
+	ret0, _ := ret[0].(*repository.DiffReportResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffReports indicates an expected call of DiffReports.
+func (mr *MockIServiceMockRecorder) DiffReports(ctx, repoId, reportId, baseId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockIService)(nil).CreateReport), ctx, repoId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffReports", reflect.TypeOf((*MockIService)(nil).DiffReports), ctx, repoId, reportId, baseId)
 }
 
 // GetById mocks base method.
@@ -82,18 +113,18 @@ func (mr *MockIServiceMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
 }
 
 // GetReportByRepoId mocks base method.
-func (m *MockIService) GetReportByRepoId(ctx context.Context, repoId string) (*model.Report, error) {
+func (m *MockIService) GetReportByRepoId(ctx context.Context, repoId string, f *model.ReportFilter) (*model.Report, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetReportByRepoId", ctx, repoId)
+	ret := m.ctrl.Call(m, "GetReportByRepoId", ctx, repoId, f)
 	ret0, _ := ret[0].(*model.Report)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetReportByRepoId indicates an expected call of GetReportByRepoId.
-func (mr *MockIServiceMockRecorder) GetReportByRepoId(ctx, repoId interface{}) *gomock.Call {
+func (mr *MockIServiceMockRecorder) GetReportByRepoId(ctx, repoId, f interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReportByRepoId", reflect.TypeOf((*MockIService)(nil).GetReportByRepoId), ctx, repoId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReportByRepoId", reflect.TypeOf((*MockIService)(nil).GetReportByRepoId), ctx, repoId, f)
 }
 
 // List mocks base method.
@@ -111,6 +142,22 @@ func (mr *MockIServiceMockRecorder) List(ctx, f interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIService)(nil).List), ctx, f)
 }
 
+// ListReports mocks base method.
+func (m *MockIService) ListReports(ctx context.Context, repoId string, f *model.ReportListFilter) ([]*model.Report, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReports", ctx, repoId, f)
+	ret0, _ := ret[0].([]*model.Report)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListReports indicates an expected call of ListReports.
+func (mr *MockIServiceMockRecorder) ListReports(ctx, repoId, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReports", reflect.TypeOf((*MockIService)(nil).ListReports), ctx, repoId, f)
+}
+
 // Remove mocks base method.
 func (m *MockIService) Remove(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -125,6 +172,34 @@ func (mr *MockIServiceMockRecorder) Remove(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockIService)(nil).Remove), ctx, id)
 }
 
+// SetBaseline mocks base method.
+func (m *MockIService) SetBaseline(ctx context.Context, repoId string, baseline *model.Baseline) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBaseline", ctx, repoId, baseline)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBaseline indicates an expected call of SetBaseline.
+func (mr *MockIServiceMockRecorder) SetBaseline(ctx, repoId, baseline interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBaseline", reflect.TypeOf((*MockIService)(nil).SetBaseline), ctx, repoId, baseline)
+}
+
+// SetPolicy mocks base method.
+func (m *MockIService) SetPolicy(ctx context.Context, repoId string, policy *app.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPolicy", ctx, repoId, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPolicy indicates an expected call of SetPolicy.
+func (mr *MockIServiceMockRecorder) SetPolicy(ctx, repoId, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPolicy", reflect.TypeOf((*MockIService)(nil).SetPolicy), ctx, repoId, policy)
+}
+
 // Update mocks base method.
 func (m *MockIService) Update(ctx context.Context, id string, req *repository.UpdateRepositoryRequest) error {
 	m.ctrl.T.Helper()